@@ -0,0 +1,120 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"time"
+
+	aw "github.com/deanishe/awgo"
+	"github.com/rwilgaard/alfred-lastpass-search/src/pkg/actions"
+	"github.com/rwilgaard/alfred-lastpass-search/src/pkg/lastpass"
+	"github.com/spf13/cobra"
+)
+
+// Values of the action_status variable set by the action command.
+const (
+	actionOK     = "ok"
+	actionFailed = "error"
+)
+
+var actionCmd = &cobra.Command{
+	Use:          "action <action> [item_id]",
+	Short:        "run an action on an entry",
+	SilenceUsage: true,
+	Args:         cobra.RangeArgs(1, 2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		wf.Configure(aw.TextErrors(true))
+
+		a, ok := actions.Find(args[0])
+		if !ok {
+			return fmt.Errorf("unknown action '%s'", args[0])
+		}
+
+		// The workflow passes the entry as variables. add_entry also reads the
+		// username and password of the new entry.
+		e := lastpass.Entry{
+			ID:       os.Getenv("item_id"),
+			Name:     os.Getenv("item_name"),
+			Folder:   os.Getenv("item_folder"),
+			URL:      os.Getenv("item_url"),
+			Username: os.Getenv("item_username"),
+			Password: os.Getenv("item_password"),
+		}
+		e.LastModified, e.LastModifiedPrecision = lastModified()
+		if len(args) > 1 {
+			e.ID = args[1]
+		}
+
+		res, err := a.Execute(cmd.Context(), ls, e)
+		return sendActionResult(a.Label, res, err)
+	},
+}
+
+// lastModified returns the modification time of the entry the workflow passes:
+// item_modified, a Unix time set by the show command, or else the listed time
+// in item_listed_modified, set on list items, which is only known to the
+// minute. It is zero without either, so write methods refuse to change the
+// entry.
+func lastModified() (time.Time, time.Duration) {
+	if secs, err := strconv.ParseInt(os.Getenv("item_modified"), 10, 64); err == nil {
+		return time.Unix(secs, 0), time.Second
+	}
+	if secs, err := strconv.ParseInt(os.Getenv("item_listed_modified"), 10, 64); err == nil {
+		return time.Unix(secs, 0), time.Minute
+	}
+	return time.Time{}, 0
+}
+
+// writeOptions makes write methods check that the entry is unchanged since the
+// copy the workflow passes.
+func writeOptions() lastpass.WriteOptions {
+	t, precision := lastModified()
+	return lastpass.WriteOptions{IfUnmodifiedSince: t, Precision: precision}
+}
+
+// sendActionResult writes the result of an action for the workflow. The
+// action_status variable tells the workflow whether to show the notification
+// as an error.
+func sendActionResult(label string, res actions.Result, err error) error {
+	if err != nil {
+		log.Printf("%s failed: %v", label, err)
+		return aw.NewArgVars().
+			Var("action_status", actionFailed).
+			Var("notification", actionErrorMessage(label, err)).
+			Send()
+	}
+
+	return aw.NewArgVars().
+		Arg(res.Value).
+		Var("action_status", actionOK).
+		Var("notification", res.Message).
+		Send()
+}
+
+// actionErrorMessage returns the notification text for a failed action.
+func actionErrorMessage(label string, err error) string {
+	switch {
+	case errors.Is(err, lastpass.ErrWrongPassword):
+		return "Wrong master password"
+	case errors.Is(err, lastpass.ErrMFARejected):
+		return "Multifactor authentication was rejected"
+	case errors.Is(err, lastpass.ErrNotLoggedIn):
+		return "You're not logged in to LastPass"
+	case errors.Is(err, lastpass.ErrNotFound):
+		return "Entry not found. Try running lpsync."
+	case errors.Is(err, lastpass.ErrNetwork):
+		return "Could not reach LastPass. Check your connection."
+	case errors.Is(err, lastpass.ErrConflict):
+		return "Entry changed remotely — review first"
+	case errors.Is(err, lastpass.ErrNoLastModified):
+		return "Could not check the entry for remote changes. Open it and try again."
+	}
+	return fmt.Sprintf("%s failed: %v", label, err)
+}
+
+func init() {
+	rootCmd.AddCommand(actionCmd)
+}
@@ -0,0 +1,149 @@
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+	"time"
+
+	aw "github.com/deanishe/awgo"
+	"github.com/rwilgaard/alfred-lastpass-search/src/pkg/lastpass"
+	"github.com/rwilgaard/alfred-lastpass-search/src/pkg/store"
+	"github.com/rwilgaard/alfred-lastpass-search/src/pkg/util"
+	"github.com/rwilgaard/go-alfredutils/alfredutils"
+	"github.com/spf13/cobra"
+)
+
+const aliasesFile = "aliases.json"
+
+var (
+	aliasCmd = &cobra.Command{
+		Use:   "alias",
+		Short: "manage entry aliases",
+	}
+	aliasAddCmd = &cobra.Command{
+		Use:          "add <alias> <id>",
+		Short:        "map an alias to an entry",
+		SilenceUsage: true,
+		Args:         cobra.ExactArgs(2),
+		RunE: func(_ *cobra.Command, args []string) error {
+			wf.Configure(aw.TextErrors(true))
+
+			aliases, err := loadAliases(profile)
+			if err != nil {
+				return err
+			}
+			if err := aliases.Set(args[0], args[1]); err != nil {
+				return err
+			}
+			if err := aliases.Save(); err != nil {
+				return err
+			}
+
+			fmt.Printf("Alias '%s' added", args[0])
+			return nil
+		},
+	}
+	aliasRemoveCmd = &cobra.Command{
+		Use:          "remove <alias>",
+		Short:        "remove an alias",
+		SilenceUsage: true,
+		Args:         cobra.ExactArgs(1),
+		RunE: func(_ *cobra.Command, args []string) error {
+			wf.Configure(aw.TextErrors(true))
+
+			aliases, err := loadAliases(profile)
+			if err != nil {
+				return err
+			}
+			if !aliases.Remove(args[0]) {
+				return fmt.Errorf("alias '%s' does not exist", args[0])
+			}
+			if err := aliases.Save(); err != nil {
+				return err
+			}
+
+			fmt.Printf("Alias '%s' removed", args[0])
+			return nil
+		},
+	}
+	aliasListCmd = &cobra.Command{
+		Use:          "list",
+		Short:        "list aliases",
+		SilenceUsage: true,
+		Args:         cobra.RangeArgs(0, 1),
+		Run: func(_ *cobra.Command, args []string) {
+			aliases, err := loadAliases(profile)
+			if err != nil {
+				wf.FatalError(err)
+			}
+
+			entries, err := ls.GetEntries("", nil, nil, false)
+			if err != nil {
+				wf.FatalError(err)
+			}
+
+			byID := make(map[string]lastpass.Entry, len(entries))
+			for _, e := range entries {
+				byID[e.ID] = e
+			}
+
+			favs, err := loadFavourites(profile)
+			if err != nil {
+				wf.FatalError(err)
+			}
+			now := time.Now()
+
+			for _, name := range aliases.Names() {
+				id := aliases.Entries[name]
+				e, ok := byID[id]
+				if !ok {
+					wf.NewItem(name).
+						Subtitle(fmt.Sprintf("⚠️ Entry %s no longer exists  •  ⌘⏎ to remove alias", id)).
+						Icon(util.IconWarn).
+						Var("alias", name).
+						Valid(false).
+						NewModifier(aw.ModCmd).
+						Subtitle("Remove alias").
+						Arg("remove").
+						Valid(true)
+					continue
+				}
+
+				pinned := favs.IsPinned(e.ID)
+				wf.NewItem(name).
+					Subtitle(fmt.Sprintf("%s  •  %s", templates.Title(e, pinned, now), templates.Subtitle(e, pinned, now))).
+					Match(fmt.Sprintf("%s %s %s", name, e.Folder, e.Name)).
+					Icon(util.IconAlias).
+					Var("alias", name).
+					Var("item_id", e.ID).
+					Var("item_name", e.Name).
+					Var("item_url", e.URL).
+					Var("item_folder", e.Folder).
+					Arg("details").
+					Valid(true).
+					NewModifier(aw.ModCmd).
+					Subtitle("Remove alias").
+					Arg("remove").
+					Valid(true)
+			}
+
+			if len(args) > 0 && args[0] != "" {
+				wf.Filter(args[0])
+			}
+
+			showTemplateErrors(templates)
+
+			wf.WarnEmpty("No aliases found", "Add one from the entry details view")
+			alfredutils.HandleFeedback(wf)
+		},
+	}
+)
+
+func loadAliases(p lastpass.Profile) (*store.Aliases, error) {
+	return store.LoadAliases(filepath.Join(wf.DataDir(), profileCacheName(aliasesFile, p)))
+}
+
+func init() {
+	aliasCmd.AddCommand(aliasAddCmd, aliasRemoveCmd, aliasListCmd)
+	rootCmd.AddCommand(aliasCmd)
+}
@@ -0,0 +1,106 @@
+package cmd
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/rwilgaard/alfred-lastpass-search/src/pkg/askpass"
+	"github.com/spf13/cobra"
+)
+
+// Prompters accepted by the askpass command.
+const (
+	prompterDialog = "dialog"
+	prompterTTY    = "tty"
+	prompterPipe   = "pipe"
+)
+
+var (
+	askPassPrompterFlag string
+	askPassCmd          = &cobra.Command{
+		Use:          "askpass <prompt>",
+		Short:        "ask for a secret for lpass, for use as LPASS_ASKPASS",
+		SilenceUsage: true,
+		Args:         cobra.ExactArgs(1),
+		Run: func(_ *cobra.Command, args []string) {
+			// lpass reads the answer from stdout, so errors must not end up there.
+			username, err := askPassUsername()
+			if err != nil {
+				askPassFailed(err)
+			}
+			c := askpass.Context{
+				Username:  username,
+				ItemName:  os.Getenv("item_name"),
+				Sensitive: os.Getenv("sensitive") == "true",
+				Previous:  askpass.PreviousPrompts(),
+			}
+
+			prompter, err := newPrompter(askPassPrompterFlag)
+			if err == nil {
+				err = askpass.Run(args[0], c, prompter, os.Stdout)
+			}
+			if err != nil {
+				askPassFailed(err)
+			}
+		},
+	}
+)
+
+// isAskPass reports whether the askpass command is being run.
+func isAskPass() bool {
+	cmd, _, err := rootCmd.Find(os.Args[1:])
+	return err == nil && cmd == askPassCmd
+}
+
+// runAskPass runs the askpass command without the workflow setup of run, which
+// reports errors as Alfred feedback on stdout, where lpass expects the answer.
+// Everything but the answer goes to stderr.
+func runAskPass() {
+	log.SetOutput(os.Stderr)
+	rootCmd.SetOut(os.Stderr)
+	rootCmd.SetErr(os.Stderr)
+	if err := rootCmd.Execute(); err != nil {
+		os.Exit(1)
+	}
+}
+
+// askPassUsername returns the username of the profile being logged in to. It
+// only reads the workflow configuration.
+func askPassUsername() (string, error) {
+	if err := wf.Config.To(cfg); err != nil {
+		return "", fmt.Errorf("error reading workflow configuration: %w", err)
+	}
+	p, err := currentProfile()
+	if err != nil {
+		return "", err
+	}
+	return p.Username, nil
+}
+
+func askPassFailed(err error) {
+	log.Printf("askpass failed: %v", err)
+	os.Exit(1)
+}
+
+func newPrompter(name string) (askpass.Prompter, error) {
+	switch name {
+	case prompterDialog:
+		icon, err := filepath.Abs("icon.png")
+		if err != nil {
+			return nil, err
+		}
+		return askpass.NewDialogPrompter(icon), nil
+	case prompterTTY:
+		return &askpass.TTYPrompter{Path: "/dev/tty"}, nil
+	case prompterPipe:
+		return &askpass.PipePrompter{In: os.Stdin, Out: os.Stderr}, nil
+	}
+	return nil, fmt.Errorf("unknown prompter '%s'", name)
+}
+
+func init() {
+	askPassCmd.Flags().StringVar(&askPassPrompterFlag, "prompter", prompterDialog, "How to ask: dialog, tty or pipe")
+	rootCmd.AddCommand(askPassCmd)
+}
@@ -0,0 +1,51 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	aw "github.com/deanishe/awgo"
+	"github.com/rwilgaard/alfred-lastpass-search/src/pkg/actions"
+	"github.com/spf13/cobra"
+)
+
+var changeCmd = &cobra.Command{
+	Use:          "change <item_id> <field>",
+	Short:        "change a field of an entry to the value read from stdin",
+	SilenceUsage: true,
+	Args:         cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		wf.Configure(aw.TextErrors(true))
+
+		itemID, field := args[0], args[1]
+		value, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			return err
+		}
+
+		// Dialogs and shells add a trailing newline.
+		newValue := strings.TrimRight(string(value), "\r\n")
+
+		label := "Change " + field
+		if err := ls.UpdateField(cmd.Context(), itemID, field, newValue, writeOptions()); err != nil {
+			return sendActionResult(label, actions.Result{}, err)
+		}
+
+		msg := fmt.Sprintf("%s of %s changed", field, itemName(itemID))
+		return sendActionResult(label, actions.Result{Value: msg, Message: msg}, nil)
+	},
+}
+
+// itemName returns the item_name variable, falling back to the item ID.
+func itemName(itemID string) string {
+	if name := os.Getenv("item_name"); name != "" {
+		return name
+	}
+	return itemID
+}
+
+func init() {
+	rootCmd.AddCommand(changeCmd)
+}
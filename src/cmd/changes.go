@@ -0,0 +1,238 @@
+package cmd
+
+import (
+	"fmt"
+	"log"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	aw "github.com/deanishe/awgo"
+	"github.com/rwilgaard/alfred-lastpass-search/src/pkg/lastpass"
+	"github.com/rwilgaard/alfred-lastpass-search/src/pkg/store"
+	"github.com/rwilgaard/alfred-lastpass-search/src/pkg/util"
+	"github.com/rwilgaard/go-alfredutils/alfredutils"
+	"github.com/spf13/cobra"
+)
+
+const (
+	snapshotsDir = "snapshots"
+	// snapshotsKept is how many syncs back the changes can be listed.
+	snapshotsKept = 50
+	// snapshotSummariesCache holds the counts shown for each snapshot, as
+	// they only change with the next snapshot.
+	snapshotSummariesCache = "snapshot_summaries.json"
+)
+
+var changesCmd = &cobra.Command{
+	Use:          "changes [query]",
+	Short:        "list vault changes since a snapshot",
+	SilenceUsage: true,
+	Args:         cobra.RangeArgs(0, 1),
+	Run: func(_ *cobra.Command, args []string) {
+		var query string
+		if len(args) > 0 {
+			query = args[0]
+		}
+
+		snaps, err := openSnapshots()
+		if err != nil {
+			wf.FatalError(err)
+		}
+		ids, err := snaps.IDs()
+		if err != nil {
+			wf.FatalError(err)
+		}
+
+		// A query starting with a snapshot ID lists the changes since it.
+		if id, rest, _ := strings.Cut(strings.TrimSpace(query), " "); slices.Contains(ids, id) {
+			showChanges(snaps, id, ids[0], strings.TrimSpace(rest))
+			return
+		}
+
+		showSnapshots(snaps, ids, query)
+	},
+}
+
+// showSnapshots lists the snapshots to pick from, each with the number of
+// changes since it.
+func showSnapshots(snaps *store.Snapshots, ids []string, query string) {
+	summaries, err := loadSnapshotSummaries(snaps, ids)
+	if err != nil {
+		wf.FatalError(err)
+	}
+
+	now := time.Now()
+	for i, id := range ids {
+		sum := summaries[id]
+		sub := fmt.Sprintf("%d entries  •  %d changes since", sum.Entries, sum.Changes)
+		if i == 0 {
+			sub = fmt.Sprintf("%d entries  •  latest snapshot", sum.Entries)
+		}
+		wf.NewItem(sum.Time.Local().Format("2006-01-02 15:04") + "  (" + util.RelativeTime(sum.Time, now) + ")").
+			Subtitle(sub).
+			Match(id).
+			Icon(util.IconSync).
+			Autocomplete(id + " ").
+			Valid(false)
+	}
+
+	if query != "" {
+		wf.Filter(query)
+	}
+
+	wf.WarnEmpty("No snapshots found", "A snapshot is taken after every sync")
+	alfredutils.HandleFeedback(wf)
+}
+
+// snapshotSummary is what the snapshot list shows for a snapshot.
+type snapshotSummary struct {
+	Time    time.Time `json:"time"`
+	Entries int       `json:"entries"`
+	// Changes is the number of changes from the snapshot to the latest one.
+	Changes int `json:"changes"`
+}
+
+// snapshotSummaries are the summaries of the snapshots up to Latest.
+type snapshotSummaries struct {
+	Latest    string                     `json:"latest"`
+	Snapshots map[string]snapshotSummary `json:"snapshots"`
+}
+
+// loadSnapshotSummaries returns the summary of each snapshot by ID. Working
+// them out loads every snapshot, so they are cached until the next snapshot
+// is taken.
+func loadSnapshotSummaries(snaps *store.Snapshots, ids []string) (map[string]snapshotSummary, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	name := profileCacheName(snapshotSummariesCache, profile)
+	var cached snapshotSummaries
+	if wf.Cache.Exists(name) {
+		if err := wf.Cache.LoadJSON(name, &cached); err != nil {
+			log.Printf("error loading snapshot summaries: %v", err)
+		}
+	}
+	if cached.Latest != ids[0] || cached.Snapshots == nil {
+		cached = snapshotSummaries{Latest: ids[0], Snapshots: make(map[string]snapshotSummary)}
+	}
+
+	var latest *store.Snapshot
+	var changed bool
+	for _, id := range ids {
+		if _, ok := cached.Snapshots[id]; ok {
+			continue
+		}
+		if latest == nil {
+			snap, err := snaps.Load(ids[0])
+			if err != nil {
+				return nil, err
+			}
+			latest = &snap
+		}
+		snap, err := snaps.Load(id)
+		if err != nil {
+			return nil, err
+		}
+		cached.Snapshots[id] = snapshotSummary{
+			Time:    snap.Time,
+			Entries: len(snap.Entries),
+			Changes: len(store.DiffSnapshots(snap, *latest)),
+		}
+		changed = true
+	}
+
+	if changed {
+		if err := wf.Cache.StoreJSON(name, cached); err != nil {
+			log.Printf("error storing snapshot summaries: %v", err)
+		}
+	}
+	return cached.Snapshots, nil
+}
+
+// showChanges lists the changes from snapshot id to the latest one.
+func showChanges(snaps *store.Snapshots, id string, latestID string, query string) {
+	older, err := snaps.Load(id)
+	if err != nil {
+		wf.FatalError(err)
+	}
+	newer, err := snaps.Load(latestID)
+	if err != nil {
+		wf.FatalError(err)
+	}
+
+	wf.NewItem("Go back").
+		Subtitle("Pick another snapshot").
+		Icon(util.IconBack).
+		Autocomplete("").
+		Valid(false)
+
+	for _, c := range store.DiffSnapshots(older, newer) {
+		title, sub, icon := changeText(c)
+		wf.NewItem(title).
+			Subtitle(sub).
+			Match(fmt.Sprintf("%s %s %s %s", c.Kind, c.Entry.Name, c.Entry.Folder, c.Old)).
+			Icon(icon).
+			Copytext(title + "  •  " + sub).
+			Valid(false)
+	}
+
+	if query != "" {
+		wf.Filter(query)
+	}
+
+	wf.WarnEmpty("No changes", "Nothing changed since "+older.Time.Local().Format("2006-01-02 15:04"))
+	alfredutils.HandleFeedback(wf)
+}
+
+func changeText(c store.Change) (string, string, *aw.Icon) {
+	e := c.Entry
+	folder := strings.TrimSuffix(e.Folder, "/")
+	switch c.Kind {
+	case store.ChangeAdded:
+		return "Added: " + e.Name, folder, util.GetIcon("default")
+	case store.ChangeRemoved:
+		return "Removed: " + e.Name, folder, util.IconDelete
+	case store.ChangeRenamed:
+		return fmt.Sprintf("Renamed: %s → %s", c.Old, e.Name), folder, util.IconEdit
+	case store.ChangeMoved:
+		return "Moved: " + e.Name, fmt.Sprintf("%s → %s", strings.TrimSuffix(c.Old, "/"), folder), util.IconFolder
+	default:
+		return "Password rotated: " + e.Name, folder, util.IconPW
+	}
+}
+
+// saveSnapshot records the metadata of entries after a sync.
+func saveSnapshot(entries []lastpass.Entry) error {
+	snaps, err := openSnapshots()
+	if err != nil {
+		return err
+	}
+
+	meta := make([]store.SnapshotEntry, 0, len(entries))
+	for _, e := range entries {
+		meta = append(meta, store.SnapshotEntry{
+			ID:                  e.ID,
+			Name:                path.Base(e.Name),
+			Folder:              e.FolderPath(),
+			URL:                 e.URL,
+			Username:            e.Username,
+			LastModified:        e.LastModified,
+			PasswordFingerprint: snaps.Fingerprint(e.Password),
+		})
+	}
+
+	_, err = snaps.Add(time.Now(), meta)
+	return err
+}
+
+func openSnapshots() (*store.Snapshots, error) {
+	return store.OpenSnapshots(filepath.Join(wf.DataDir(), profileCacheName(snapshotsDir, profile)), snapshotsKept)
+}
+
+func init() {
+	rootCmd.AddCommand(changesCmd)
+}
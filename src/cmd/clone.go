@@ -0,0 +1,59 @@
+package cmd
+
+import (
+	"fmt"
+
+	aw "github.com/deanishe/awgo"
+	"github.com/rwilgaard/alfred-lastpass-search/src/pkg/actions"
+	"github.com/rwilgaard/alfred-lastpass-search/src/pkg/lastpass"
+	"github.com/rwilgaard/alfred-lastpass-search/src/pkg/util"
+	"github.com/spf13/cobra"
+)
+
+var (
+	cloneFolderFlag      string
+	cloneNewPasswordFlag bool
+	cloneCmd             = &cobra.Command{
+		Use:          "clone <item_id> <name>",
+		Short:        "copy an entry under a new name",
+		SilenceUsage: true,
+		Args:         cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			wf.Configure(aw.TextErrors(true))
+
+			itemID, name := args[0], args[1]
+			opts := lastpass.DuplicateOptions{
+				Name:   name,
+				Folder: cloneFolderFlag,
+			}
+			if cloneNewPasswordFlag {
+				pw, err := util.GeneratePassword(passwordLengthDefault, true, cfg.AllowedSymbols)
+				if err != nil {
+					return err
+				}
+				opts.Password = pw
+			}
+
+			label := "Clone " + itemName(itemID)
+			id, err := ls.DuplicateEntry(cmd.Context(), itemID, opts)
+			if err != nil {
+				if id != "" {
+					err = fmt.Errorf("copied as %s, which could not be removed again: %w", id, err)
+				}
+				return sendActionResult(label, actions.Result{}, err)
+			}
+
+			msg := fmt.Sprintf("%s cloned as %s", itemName(itemID), name)
+			if cloneNewPasswordFlag {
+				msg += " with a new password"
+			}
+			return sendActionResult(label, actions.Result{Value: id, Message: msg}, nil)
+		},
+	}
+)
+
+func init() {
+	cloneCmd.Flags().StringVar(&cloneFolderFlag, "folder", "", "Folder to put the copy in")
+	cloneCmd.Flags().BoolVar(&cloneNewPasswordFlag, "new-password", false, "Generate a new password for the copy")
+	rootCmd.AddCommand(cloneCmd)
+}
@@ -0,0 +1,298 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	aw "github.com/deanishe/awgo"
+	"github.com/rwilgaard/alfred-lastpass-search/src/pkg/actions"
+	"github.com/rwilgaard/alfred-lastpass-search/src/pkg/lastpass"
+	"github.com/rwilgaard/alfred-lastpass-search/src/pkg/util"
+	"github.com/rwilgaard/go-alfredutils/alfredutils"
+	"github.com/spf13/cobra"
+)
+
+// iconFields are the fields util.GetIcon has an icon for. Other fields use
+// icons/default.png.
+var iconFields = []string{"default", "password", "username", "url", "notes"}
+
+// doctorCheck is the outcome of one diagnostic check.
+type doctorCheck struct {
+	Name   string
+	OK     bool
+	Detail string
+	// Hint tells how to fix a failed check.
+	Hint string
+}
+
+func (c doctorCheck) String() string {
+	if c.OK {
+		return fmt.Sprintf("[ok]   %s: %s", c.Name, c.Detail)
+	}
+	return fmt.Sprintf("[fail] %s: %s. %s", c.Name, c.Detail, c.Hint)
+}
+
+var doctorCmd = &cobra.Command{
+	Use:          "doctor",
+	Short:        "check that lpass and the workflow are set up correctly",
+	SilenceUsage: true,
+	Args:         cobra.NoArgs,
+	Annotations: map[string]string{
+		annotationSkipLoginCheck: "true",
+		annotationOptionalLpass:  "true",
+	},
+	Run: func(cmd *cobra.Command, _ []string) {
+		checks := runDoctorChecks(cmd.Context())
+
+		lines := make([]string, 0, len(checks))
+		var failed int
+		for _, c := range checks {
+			lines = append(lines, c.String())
+			if !c.OK {
+				failed++
+			}
+		}
+		report := strings.Join(lines, "\n")
+
+		title := fmt.Sprintf("All %d checks passed", len(checks))
+		if failed > 0 {
+			title = fmt.Sprintf("%d of %d checks failed", failed, len(checks))
+		}
+		wf.NewItem(title).
+			Subtitle("Press ⏎ to copy the report").
+			Icon(doctorIcon(failed == 0)).
+			Arg(report).
+			Copytext(report).
+			Valid(true)
+
+		for _, c := range checks {
+			subtitle := c.Detail
+			if !c.OK {
+				subtitle = c.Hint
+			}
+			wf.NewItem(c.Name).
+				Subtitle(subtitle).
+				Icon(doctorIcon(c.OK)).
+				Arg(report).
+				Copytext(c.String()).
+				Valid(true)
+		}
+
+		alfredutils.HandleFeedback(wf)
+	},
+}
+
+// runDoctorChecks runs every check. Checks that need lpass are skipped when it
+// can't be found.
+func runDoctorChecks(ctx context.Context) []doctorCheck {
+	lpassCheck := checkLpassPath()
+	checks := []doctorCheck{lpassCheck}
+	if lpassCheck.OK {
+		checks = append(checks, checkLpassVersion(ctx), checkAgent(ctx))
+	}
+
+	checks = append(checks, checkModifierActions(), checkAllowedSymbols(), checkTemplates())
+	if lpassCheck.OK {
+		checks = append(checks, checkPrivateFolders())
+	}
+	checks = append(checks,
+		checkWritable("Cache directory", wf.CacheDir()),
+		checkWritable("Data directory", wf.DataDir()),
+		checkIcons(),
+	)
+
+	return checks
+}
+
+func checkLpassPath() doctorCheck {
+	c := doctorCheck{Name: "lpass installed"}
+	path, err := lastpass.FindBinary(cfg.LpassPath)
+	if err != nil {
+		c.Detail = err.Error()
+		c.Hint = "Install it with brew install lastpass-cli or set lpass_path in the workflow configuration"
+		return c
+	}
+	c.OK, c.Detail = true, path
+	return c
+}
+
+func checkLpassVersion(ctx context.Context) doctorCheck {
+	c := doctorCheck{Name: "lpass version"}
+	version, err := ls.Version(ctx)
+	if err != nil {
+		c.Detail = err.Error()
+		c.Hint = "Reinstall lastpass-cli"
+		return c
+	}
+	c.Detail = version
+	if !lastpass.SupportedVersion(version) {
+		c.Detail += " is not supported"
+		c.Hint = fmt.Sprintf("Upgrade lastpass-cli to %s or newer", lastpass.MinVersion)
+		return c
+	}
+	c.OK = true
+	return c
+}
+
+func checkAgent(ctx context.Context) doctorCheck {
+	c := doctorCheck{Name: "lpass agent"}
+	st, err := ls.Status(ctx)
+	switch {
+	case err != nil:
+		c.Detail = err.Error()
+		c.Hint = "Check the output of lpass status"
+	case !st.LoggedIn:
+		c.Detail = "Not logged in"
+		c.Hint = "Log in from any search keyword"
+	default:
+		c.OK, c.Detail = true, "Logged in as "+st.Account
+	}
+	return c
+}
+
+func checkModifierActions() doctorCheck {
+	c := doctorCheck{Name: "Modifier actions"}
+
+	// ⏎ and the modifier keys must map to an action that works on an entry.
+	// Only the optional keys may be unmapped.
+	keys := append([]modifierAction{{key: "return", action: cfg.ModifierReturn}}, modifierActions()...)
+	var problems []string
+	for _, m := range keys {
+		if m.action == "" {
+			if m.key != aw.ModShift && m.key != aw.ModFn {
+				problems = append(problems, m.key+" has no action")
+			}
+			continue
+		}
+		a, ok := actions.Find(m.action)
+		switch {
+		case !ok:
+			problems = append(problems, fmt.Sprintf("%s has unknown action '%s'", m.key, m.action))
+		case a.Kind == actions.KindChange:
+			problems = append(problems, fmt.Sprintf("%s can't run '%s'", m.key, a.Label))
+		}
+	}
+
+	if len(problems) > 0 {
+		c.Detail = strings.Join(problems, ", ")
+		c.Hint = "Pick another action in the workflow configuration"
+		return c
+	}
+	c.OK, c.Detail = true, "Every key has a valid action"
+	return c
+}
+
+func checkAllowedSymbols() doctorCheck {
+	c := doctorCheck{Name: "Allowed symbols"}
+	if _, err := util.GeneratePassword(passwordLengthDefault, true, cfg.AllowedSymbols); err != nil {
+		c.Detail = err.Error()
+		c.Hint = "Change allowed_symbols in the workflow configuration"
+		return c
+	}
+	c.OK, c.Detail = true, fmt.Sprintf("Passwords use %q", cfg.AllowedSymbols)
+	return c
+}
+
+func checkTemplates() doctorCheck {
+	c := doctorCheck{Name: "Templates"}
+	if len(templates.errs) > 0 {
+		msgs := make([]string, 0, len(templates.errs))
+		for _, err := range templates.errs {
+			msgs = append(msgs, err.Error())
+		}
+		c.Detail = strings.Join(msgs, ", ")
+		c.Hint = "Fix title_template or subtitle_template in the workflow configuration"
+		return c
+	}
+	c.OK, c.Detail = true, "Title and subtitle templates are valid"
+	return c
+}
+
+func checkPrivateFolders() doctorCheck {
+	c := doctorCheck{Name: "Private folders"}
+	if len(cfg.PrivateFolders) == 0 {
+		c.OK, c.Detail = true, "None configured"
+		return c
+	}
+
+	folders, err := ls.GetFolders()
+	if err != nil {
+		c.Detail = err.Error()
+		c.Hint = "Log in to check the private folders"
+		return c
+	}
+	existing := make(map[string]bool, len(folders))
+	for _, f := range folders {
+		existing[strings.TrimSuffix(f.Name, "/")] = true
+	}
+
+	var missing []string
+	for _, f := range strings.Split(cfg.PrivateFolders, ",") {
+		if f = strings.Trim(strings.TrimSpace(f), "/"); f != "" && !existing[f] {
+			missing = append(missing, f)
+		}
+	}
+	if len(missing) > 0 {
+		c.Detail = "Not found: " + strings.Join(missing, ", ")
+		c.Hint = "Fix private_folders in the workflow configuration"
+		return c
+	}
+	c.OK, c.Detail = true, cfg.PrivateFolders
+	return c
+}
+
+func checkWritable(name string, dir string) doctorCheck {
+	c := doctorCheck{Name: name}
+	f, err := os.CreateTemp(dir, "doctor-*")
+	if err != nil {
+		c.Detail = err.Error()
+		c.Hint = fmt.Sprintf("Make %s writable", dir)
+		return c
+	}
+	_ = f.Close()
+	_ = os.Remove(f.Name())
+
+	c.OK, c.Detail = true, dir
+	return c
+}
+
+func checkIcons() doctorCheck {
+	c := doctorCheck{Name: "Icons"}
+
+	icons := []*aw.Icon{
+		util.IconFolder, util.IconBack, util.IconSN, util.IconPW, util.IconDelete, util.IconEdit,
+		util.IconPin, util.IconAlias, util.IconWarn, util.IconClone, util.IconSync, util.IconLock,
+		util.IconLogout, util.IconOK,
+	}
+	for _, f := range iconFields {
+		icons = append(icons, &aw.Icon{Value: filepath.Join("icons", f+".png")})
+	}
+
+	var missing []string
+	for _, icon := range icons {
+		if _, err := os.Stat(icon.Value); err != nil {
+			missing = append(missing, icon.Value)
+		}
+	}
+	if len(missing) > 0 {
+		c.Detail = "Missing: " + strings.Join(missing, ", ")
+		c.Hint = "Reinstall the workflow"
+		return c
+	}
+	c.OK, c.Detail = true, fmt.Sprintf("%d icons found", len(icons))
+	return c
+}
+
+func doctorIcon(ok bool) *aw.Icon {
+	if ok {
+		return util.IconOK
+	}
+	return util.IconWarn
+}
+
+func init() {
+	rootCmd.AddCommand(doctorCmd)
+}
@@ -0,0 +1,87 @@
+package cmd
+
+import (
+	"fmt"
+	"log"
+	"path/filepath"
+
+	aw "github.com/deanishe/awgo"
+	"github.com/rwilgaard/alfred-lastpass-search/src/pkg/lastpass"
+	"github.com/rwilgaard/alfred-lastpass-search/src/pkg/store"
+	"github.com/spf13/cobra"
+)
+
+const favouritesFile = "favourites.json"
+
+var (
+	favouritesCmd = &cobra.Command{
+		Use:   "favourites",
+		Short: "manage pinned entries",
+	}
+	favouritesToggleCmd = &cobra.Command{
+		Use:          "toggle",
+		Short:        "pin or unpin an entry",
+		SilenceUsage: true,
+		Args:         cobra.ExactArgs(1),
+		RunE: func(_ *cobra.Command, args []string) error {
+			wf.Configure(aw.TextErrors(true))
+
+			favs, err := loadFavourites(profile)
+			if err != nil {
+				return err
+			}
+
+			pinned := favs.Toggle(args[0])
+			if err := favs.Save(); err != nil {
+				return err
+			}
+
+			name := itemName(args[0])
+			if pinned {
+				fmt.Printf("%s pinned", name)
+			} else {
+				fmt.Printf("%s unpinned", name)
+			}
+			return nil
+		},
+	}
+	favouritesPruneCmd = &cobra.Command{
+		Use:          "prune",
+		Short:        "drop pins for entries that no longer exist",
+		SilenceUsage: true,
+		Args:         cobra.NoArgs,
+		RunE: func(_ *cobra.Command, _ []string) error {
+			wf.Configure(aw.TextErrors(true))
+
+			favs, err := loadFavourites(profile)
+			if err != nil {
+				return err
+			}
+
+			entries, err := ls.GetEntries("", nil, nil, false)
+			if err != nil {
+				return err
+			}
+
+			ids := make([]string, 0, len(entries))
+			for _, e := range entries {
+				ids = append(ids, e.ID)
+			}
+
+			if removed := favs.Prune(ids); removed > 0 {
+				log.Printf("Removed %d stale pin(s)", removed)
+				return favs.Save()
+			}
+			return nil
+		},
+	}
+)
+
+func loadFavourites(p lastpass.Profile) (*store.Favourites, error) {
+	return store.LoadFavourites(filepath.Join(wf.DataDir(), profileCacheName(favouritesFile, p)))
+}
+
+func init() {
+	favouritesCmd.AddCommand(favouritesToggleCmd, favouritesPruneCmd)
+	rootCmd.AddCommand(favouritesCmd)
+}
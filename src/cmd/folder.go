@@ -0,0 +1,71 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	aw "github.com/deanishe/awgo"
+	"github.com/rwilgaard/alfred-lastpass-search/src/pkg/actions"
+	"github.com/spf13/cobra"
+)
+
+var (
+	folderCmd = &cobra.Command{
+		Use:   "folder",
+		Short: "create, rename and delete folders",
+	}
+	folderCreateCmd = &cobra.Command{
+		Use:          "create <folder>",
+		Short:        "create an empty folder",
+		SilenceUsage: true,
+		Args:         cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			wf.Configure(aw.TextErrors(true))
+
+			folder := strings.Trim(args[0], "/")
+			err := ls.CreateFolder(cmd.Context(), folder)
+			return sendFolderResult("Create folder", fmt.Sprintf("Folder %s created", folder), err)
+		},
+	}
+	folderRenameCmd = &cobra.Command{
+		Use:          "rename <folder> <new name>",
+		Short:        "rename a folder by moving all its entries",
+		SilenceUsage: true,
+		Args:         cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			wf.Configure(aw.TextErrors(true))
+
+			from, to := strings.Trim(args[0], "/"), strings.Trim(args[1], "/")
+			err := ls.RenameFolder(cmd.Context(), from, to)
+			return sendFolderResult("Rename folder", fmt.Sprintf("Folder %s renamed to %s", from, to), err)
+		},
+	}
+	folderDeleteCmd = &cobra.Command{
+		Use:          "delete <folder>",
+		Short:        "delete an empty folder",
+		SilenceUsage: true,
+		Args:         cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			wf.Configure(aw.TextErrors(true))
+
+			folder := strings.Trim(args[0], "/")
+			err := ls.DeleteEmptyFolder(cmd.Context(), folder)
+			return sendFolderResult("Delete folder", fmt.Sprintf("Folder %s deleted", folder), err)
+		},
+	}
+)
+
+// sendFolderResult reports the outcome of a folder change.
+func sendFolderResult(label string, msg string, err error) error {
+	if err != nil {
+		return sendActionResult(label, actions.Result{}, err)
+	}
+	return sendActionResult(label, actions.Result{Value: msg, Message: msg}, nil)
+}
+
+func init() {
+	folderCmd.AddCommand(folderCreateCmd)
+	folderCmd.AddCommand(folderRenameCmd)
+	folderCmd.AddCommand(folderDeleteCmd)
+	rootCmd.AddCommand(folderCmd)
+}
@@ -1,46 +1,248 @@
 package cmd
 
 import (
+	"encoding/csv"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	aw "github.com/deanishe/awgo"
 	"github.com/rwilgaard/alfred-lastpass-search/src/pkg/lastpass"
+	"github.com/rwilgaard/alfred-lastpass-search/src/pkg/store"
 	"github.com/rwilgaard/alfred-lastpass-search/src/pkg/util"
 	"github.com/rwilgaard/go-alfredutils/alfredutils"
 	"github.com/spf13/cobra"
 )
 
-var foldersCmd = &cobra.Command{
-	Use:          "folders",
-	Short:        "list folders",
-	SilenceUsage: true,
-	Args:         cobra.ExactArgs(1),
-	Run: func(_ *cobra.Command, args []string) {
-		ls, err := lastpass.NewService("lpass")
-		if err != nil {
-			wf.FatalError(err)
-		}
+const exclusionsFile = "excluded_folders.json"
 
-		folders, err := ls.GetFolders()
-		if err != nil {
-			wf.FatalError(err)
-		}
+var (
+	manageFlag bool
+	multiFlag  bool
+	foldersCmd = &cobra.Command{
+		Use:          "folders",
+		Short:        "list folders",
+		SilenceUsage: true,
+		Args:         cobra.ExactArgs(1),
+		Run: func(_ *cobra.Command, args []string) {
+			folders, err := ls.GetFolders()
+			if err != nil {
+				wf.FatalError(err)
+			}
+			lastpass.SetSharePermissions(folders, cachedSharePermissions())
+
+			exclusions, err := loadExclusions(profile)
+			if err != nil {
+				wf.FatalError(err)
+			}
+
+			selected := splitFolders(os.Getenv("selected_folders"))
+			if multiFlag && len(selected) > 0 {
+				wf.NewItem(fmt.Sprintf("Search %d selected folder(s)", len(selected))).
+					Match("*").
+					Subtitle(strings.Join(selected, "  •  ")).
+					Icon(util.IconFolder).
+					Var("folder", joinFolders(selected)).
+					Var("selected_folders", "").
+					Valid(true)
+			} else {
+				wf.NewItem("Select folder").
+					Match("*").
+					Subtitle("Type to search").
+					Valid(false)
+			}
+
+			now := time.Now()
+
+			for _, f := range folders {
+				sub := f.Subtitle(now)
+
+				isSelected := multiFlag && slices.Contains(selected, f.Name)
+				title := f.Name
+				if isSelected {
+					title = "✓ " + title
+				}
+
+				it := wf.NewItem(title).
+					Subtitle(sub).
+					Match(f.Name).
+					UID(f.Name).
+					Icon(util.IconFolder).
+					Var("folder", f.Name).
+					Var("folder_shared", strconv.FormatBool(f.Shared)).
+					Valid(true)
+
+				if multiFlag {
+					// ⏎ searches the whole selection including this folder.
+					it.Var("folder", joinFolders(withFolder(selected, f.Name))).
+						Var("selected_folders", "")
+					if isSelected {
+						it.NewModifier(aw.ModCmd).
+							Subtitle("Remove folder from selection").
+							Var("selected_folders", joinFolders(withoutFolder(selected, f.Name))).
+							Valid(true)
+					} else {
+						it.NewModifier(aw.ModCmd).
+							Subtitle("Add folder to selection").
+							Var("selected_folders", joinFolders(withFolder(selected, f.Name))).
+							Valid(true)
+					}
+				}
+
+				if !manageFlag {
+					continue
+				}
+
+				if f.EntryCount == 0 {
+					it.NewModifier(aw.ModShift).
+						Subtitle("Delete empty folder").
+						Var("folder", f.Name).
+						Valid(true)
+				}
+				if !f.Shared || strings.Count(strings.TrimSuffix(f.Name, "/"), "/") > 0 {
+					it.NewModifier(aw.ModCtrl).
+						Subtitle("Rename folder…").
+						Var("folder", f.Name).
+						Valid(true)
+				}
+
+				switch {
+				case lastpass.MatchFolder(configExclusions(), f.Name):
+					it.Subtitle(sub + "  •  Excluded from lp by the workflow configuration")
+				case exclusions.IsExcluded(f.Name):
+					it.Subtitle(sub + "  •  Excluded from lp")
+					it.NewModifier(aw.ModOpt).
+						Subtitle("Include folder in lp").
+						Var("folder", f.Name).
+						Valid(true)
+				default:
+					it.NewModifier(aw.ModOpt).
+						Subtitle("Exclude folder from lp").
+						Var("folder", f.Name).
+						Valid(true)
+				}
+			}
+
+			wf.Filter(args[0])
+
+			if manageFlag && args[0] != "" && !slices.ContainsFunc(folders, func(f lastpass.Folder) bool {
+				return strings.EqualFold(strings.TrimSuffix(f.Name, "/"), strings.Trim(args[0], "/"))
+			}) {
+				wf.NewItem(fmt.Sprintf("Create folder '%s'", strings.Trim(args[0], "/"))).
+					Subtitle("Press ⏎ to create an empty folder").
+					Icon(util.IconFolder).
+					Var("folder", strings.Trim(args[0], "/")).
+					Var("folder_action", "create").
+					Valid(true)
+			}
+
+			alfredutils.HandleFeedback(wf)
+		},
+	}
+	excludeCmd = &cobra.Command{
+		Use:          "exclude <folder>",
+		Short:        "toggle whether a folder is excluded from the global search",
+		SilenceUsage: true,
+		Args:         cobra.ExactArgs(1),
+		RunE: func(_ *cobra.Command, args []string) error {
+			wf.Configure(aw.TextErrors(true))
 
-		wf.NewItem("Select folder").
-			Match("*").
-			Subtitle("Type to search").
-			Valid(false)
-
-		for _, f := range folders {
-			wf.NewItem(f.Name).
-				UID(f.Name).
-				Icon(util.IconFolder).
-				Var("folder", f.Name).
-				Valid(true)
+			exclusions, err := loadExclusions(profile)
+			if err != nil {
+				return err
+			}
+
+			excluded := exclusions.Toggle(args[0])
+			if err := exclusions.Save(); err != nil {
+				return err
+			}
+
+			folder := strings.Trim(args[0], "/")
+			if excluded {
+				fmt.Printf("%s excluded from search", folder)
+			} else {
+				fmt.Printf("%s included in search", folder)
+			}
+			return nil
+		},
+	}
+)
+
+func loadExclusions(p lastpass.Profile) (*store.Exclusions, error) {
+	return store.LoadExclusions(filepath.Join(wf.DataDir(), profileCacheName(exclusionsFile, p)))
+}
+
+// withFolder returns a copy of folders with folder appended if it isn't already present.
+func withFolder(folders []string, folder string) []string {
+	folders = slices.Clone(folders)
+	if !slices.Contains(folders, folder) {
+		folders = append(folders, folder)
+	}
+	return folders
+}
+
+// withoutFolder returns a copy of folders with folder removed.
+func withoutFolder(folders []string, folder string) []string {
+	return slices.DeleteFunc(slices.Clone(folders), func(f string) bool {
+		return f == folder
+	})
+}
+
+// joinFolders joins folder names as CSV, the format expected by the --folders flag.
+func joinFolders(folders []string) string {
+	if len(folders) == 0 {
+		return ""
+	}
+	var sb strings.Builder
+	w := csv.NewWriter(&sb)
+	_ = w.Write(folders)
+	w.Flush()
+	return strings.TrimSuffix(sb.String(), "\n")
+}
+
+// splitFolders is the inverse of joinFolders.
+func splitFolders(value string) []string {
+	if value == "" {
+		return nil
+	}
+	folders, err := csv.NewReader(strings.NewReader(value)).Read()
+	if err != nil {
+		return nil
+	}
+	return folders
+}
+
+// configExclusions returns the exclusion globs from the workflow configuration.
+func configExclusions() []string {
+	var patterns []string
+	for _, p := range strings.Split(cfg.ExcludedFolders, ",") {
+		if p = strings.TrimSpace(p); p != "" {
+			patterns = append(patterns, p)
 		}
+	}
+	return patterns
+}
 
-		wf.Filter(args[0])
-		alfredutils.HandleFeedback(wf)
-	},
+// excludedFolders returns every folder pattern excluded from the global search.
+func excludedFolders() ([]string, error) {
+	exclusions, err := loadExclusions(profile)
+	if err != nil {
+		return nil, err
+	}
+	// Toggled folders are names, not globs.
+	patterns := configExclusions()
+	for _, f := range exclusions.Folders {
+		patterns = append(patterns, lastpass.QuoteFolder(f))
+	}
+	return patterns, nil
 }
 
 func init() {
-	rootCmd.AddCommand(foldersCmd)
+	foldersCmd.Flags().BoolVar(&manageFlag, "manage", false, "Allow excluding folders from the global search")
+	foldersCmd.Flags().BoolVar(&multiFlag, "multi", false, "Allow selecting several folders")
+	rootCmd.AddCommand(foldersCmd, excludeCmd)
 }
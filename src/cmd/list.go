@@ -2,15 +2,32 @@ package cmd
 
 import (
 	"fmt"
+	"slices"
+	"sort"
+	"strconv"
+	"time"
 
 	aw "github.com/deanishe/awgo"
+	"github.com/rwilgaard/alfred-lastpass-search/src/pkg/actions"
+	"github.com/rwilgaard/alfred-lastpass-search/src/pkg/lastpass"
+	"github.com/rwilgaard/alfred-lastpass-search/src/pkg/store"
+	"github.com/rwilgaard/alfred-lastpass-search/src/pkg/util"
 	"github.com/rwilgaard/go-alfredutils/alfredutils"
 	"github.com/spf13/cobra"
 )
 
+const (
+	totpAction        = "copy_totp"
+	totpEntriesCache  = "totp_entries.json"
+	totpEntriesMaxAge = 24 * time.Hour
+)
+
 var (
-	foldersFlag []string
-	listCmd     = &cobra.Command{
+	foldersFlag    []string
+	sortFlag       string
+	favouritesFlag bool
+	scopedFlag     bool
+	listCmd        = &cobra.Command{
 		Use:          "list",
 		Short:        "list entries",
 		SilenceUsage: false,
@@ -21,57 +38,349 @@ var (
 				query = args[0]
 			}
 
-			entries, err := ls.GetEntries(query, foldersFlag, cfg.FuzzySearch)
+			scope, err := activeScope()
 			if err != nil {
 				wf.FatalError(err)
 			}
 
-			for _, e := range entries {
-				it := wf.NewItem(e.Name).
-					Subtitle(fmt.Sprintf("%s  •  ID: %s", e.Folder, e.ID)).
-					Match(fmt.Sprintf("%s %s %s %s", e.ID, e.Folder, e.Name, e.URL)).
-					UID(e.ID).
-					Var("item_id", e.ID).
-					Var("item_name", e.Name).
-					Var("item_url", e.URL).
-					Var("item_folder", e.Folder).
-					Var("query", query).
-					Var("action", cfg.ModifierReturn).
-					Valid(ls.CheckValidity(e, cfg.ModifierReturn))
-
-				if ls.CheckValidity(e, cfg.ModifierCtrl) {
-					it.NewModifier(aw.ModCtrl).
-						Subtitle(cfg.ModifierCtrl).
-						Var("action", cfg.ModifierCtrl).
-						Valid(true)
+			// An active scope replaces the folders passed by lpp. Exclusions never
+			// apply to an explicit folder search with lpf.
+			folders := foldersFlag
+			var exclude []string
+			if scopedFlag || len(folders) == 0 {
+				exclude, err = excludedFolders()
+				if err != nil {
+					wf.FatalError(err)
+				}
+				if scope != nil {
+					exclude = append(exclude, scope.Exclude...)
+				}
+			}
+			if scopedFlag && scope != nil {
+				folders = nil
+			}
+
+			aliases, err := loadAliases(profile)
+			if err != nil {
+				wf.FatalError(err)
+			}
+
+			// An exact alias match jumps straight to its entry, even if the entry
+			// itself doesn't match the query, so the listing can't be narrowed by
+			// the query then.
+			aliasID, aliased := aliases.Lookup(query)
+			listQuery := query
+			if aliased {
+				listQuery = ""
+			}
+
+			entries, err := searchEntries(listQuery, folders, exclude)
+			if err != nil {
+				wf.FatalError(err)
+			}
+
+			// Aliases belong to the current profile, so only its entry can match.
+			var aliasEntry *accountEntry
+			if aliased {
+				isAlias := func(e accountEntry) bool {
+					return e.ID == aliasID && e.account().Name == profile.Name
+				}
+				if i := slices.IndexFunc(entries, isAlias); i >= 0 {
+					e := entries[i]
+					aliasEntry = &e
 				}
+				entries = slices.DeleteFunc(entries, func(e accountEntry) bool {
+					return isAlias(e) || (!cfg.FuzzySearch && !e.Matches(query))
+				})
+			}
+
+			if scopedFlag && scope != nil {
+				entries = slices.DeleteFunc(entries, func(e accountEntry) bool {
+					return !scope.Includes(e.FolderPath())
+				})
+			}
 
-				if ls.CheckValidity(e, cfg.ModifierOpt) {
-					it.NewModifier(aw.ModOpt).
-						Subtitle(cfg.ModifierOpt).
-						Var("action", cfg.ModifierOpt).
-						Valid(true)
+			if err := lastpass.SortEntriesFunc(entries, sortFlag, func(e accountEntry) lastpass.Entry { return e.Entry }); err != nil {
+				wf.FatalError(err)
+			}
+
+			// Pins and TOTP secrets are looked up in the vault of each entry.
+			favs := make(map[string]*store.Favourites)
+			totp := make(map[string]map[string]bool)
+			load := func(e accountEntry) {
+				p := e.account()
+				if _, ok := favs[p.Name]; ok {
+					return
 				}
+				if favs[p.Name], err = loadFavourites(p); err != nil {
+					wf.FatalError(err)
+				}
+				if totp[p.Name], err = totpEntries(p); err != nil {
+					wf.FatalError(err)
+				}
+			}
+			for _, e := range entries {
+				load(e)
+			}
+			if aliasEntry != nil {
+				load(*aliasEntry)
+			}
+			isPinned := func(e accountEntry) bool {
+				return favs[e.account().Name].IsPinned(e.ID)
+			}
+
+			if favouritesFlag {
+				entries = slices.DeleteFunc(entries, func(e accountEntry) bool {
+					return !isPinned(e)
+				})
+			}
+
+			// Pinned entries always come first.
+			sort.SliceStable(entries, func(i, j int) bool {
+				return isPinned(entries[i]) && !isPinned(entries[j])
+			})
+
+			now := time.Now()
 
-				if ls.CheckValidity(e, cfg.ModifierCmd) {
-					it.NewModifier(aw.ModCmd).
-						Subtitle(cfg.ModifierCmd).
-						Var("action", cfg.ModifierCmd).
-						Valid(true)
+			pinnedItems := make(map[*aw.Item]bool)
+			for _, e := range entries {
+				e.HasTOTP = totp[e.account().Name][e.ID]
+				pinned := isPinned(e)
+				title := templates.Title(e.Entry, pinned, now)
+				if pinned {
+					title = "★ " + title
+				}
+				sub := entrySubtitle(templates, e.Entry, pinned, now)
+				if e.Profile.Name != "" {
+					sub = e.Profile.Username + "  •  " + sub
+				}
+				it := newEntryItem(e.Entry, query, title, sub, e.Profile)
+				if pinned {
+					pinnedItems[it] = true
 				}
 			}
 
 			if cfg.FuzzySearch && len(query) > 0 {
 				wf.Filter(query)
+				// Filtering orders the items by match score, so put the pinned
+				// entries first again.
+				items := wf.Feedback.Items
+				sort.SliceStable(items, func(i, j int) bool {
+					return pinnedItems[items[i]] && !pinnedItems[items[j]]
+				})
 			}
 
+			// The alias match goes above the filtered results.
+			if aliasEntry != nil {
+				e := *aliasEntry
+				e.HasTOTP = totp[e.account().Name][e.ID]
+				pinned := isPinned(e)
+				n := len(wf.Feedback.Items)
+				sub := fmt.Sprintf("Alias: %s  •  %s", query, entrySubtitle(templates, e.Entry, pinned, now))
+				newEntryItem(e.Entry, query, templates.Title(e.Entry, pinned, now), sub, e.Profile).
+					Icon(util.IconAlias)
+				wf.Feedback.Items = slices.Concat(wf.Feedback.Items[n:], wf.Feedback.Items[:n])
+			}
+
+			showTemplateErrors(templates)
+
 			alfredutils.HandleFeedback(wf)
 		},
 	}
 )
 
+// accountEntry is an entry with the profile of the account it was found in.
+// The profile is empty when only the current profile is searched.
+type accountEntry struct {
+	lastpass.Entry
+	Profile lastpass.Profile
+}
+
+// account returns the profile whose vault the entry is in.
+func (e accountEntry) account() lastpass.Profile {
+	if e.Profile.Name == "" {
+		return profile
+	}
+	return e.Profile
+}
+
+// searchEntries returns the entries of the current profile. With
+// search_all_profiles set, every logged-in profile is searched and each entry
+// is returned with its profile, as accounts can share entries with the same ID.
+func searchEntries(query string, folders []string, exclude []string) ([]accountEntry, error) {
+	services := []profileService{{Service: ls}}
+	if cfg.SearchAllProfiles {
+		loggedIn, err := loggedInServices()
+		if err != nil {
+			return nil, err
+		}
+		if len(loggedIn) > 1 {
+			services = loggedIn
+		}
+	}
+
+	var entries []accountEntry
+	for _, s := range services {
+		found, err := s.Service.GetEntries(query, folders, exclude, cfg.FuzzySearch)
+		if err != nil {
+			if s.Profile.Name != "" {
+				err = fmt.Errorf("error searching %s: %w", s.Profile.Name, err)
+			}
+			return nil, err
+		}
+		for _, e := range found {
+			entries = append(entries, accountEntry{Entry: e, Profile: s.Profile})
+		}
+	}
+	return entries, nil
+}
+
+func entrySubtitle(tmpl *itemTemplates, e lastpass.Entry, pinned bool, now time.Time) string {
+	sub := tmpl.Subtitle(e, pinned, now)
+	switch sortFlag {
+	case lastpass.SortRecentUsed:
+		sub += "  •  used " + util.RelativeTime(e.LastUsed, now)
+	case lastpass.SortRecentModified:
+		sub += "  •  modified " + util.RelativeTime(e.LastModified, now)
+	}
+	return sub
+}
+
+// showTemplateErrors puts an item for each template error at the top of the results.
+func showTemplateErrors(tmpl *itemTemplates) {
+	n := len(wf.Feedback.Items)
+	for _, err := range tmpl.errs {
+		wf.NewItem(err.Error()).
+			Subtitle("Using the default template. Fix it in the User Configuration.").
+			Icon(util.IconWarn).
+			Valid(false)
+	}
+	wf.Feedback.Items = slices.Concat(wf.Feedback.Items[n:], wf.Feedback.Items[:n])
+}
+
+// newEntryItem creates the item for e. A named account profile is passed to
+// the actions in the profile variable, so they run against its vault.
+func newEntryItem(e lastpass.Entry, query string, title string, sub string, account lastpass.Profile) *aw.Item {
+	it := wf.NewItem(title).
+		Subtitle(sub).
+		Match(fmt.Sprintf("%s %s %s %s %s", e.ID, e.Folder, e.Name, e.URL, account.Username)).
+		UID(profileCacheName(e.ID, account)).
+		Var("item_id", e.ID).
+		Var("item_name", e.Name).
+		Var("item_url", e.URL).
+		Var("item_folder", e.Folder).
+		Var("query", query).
+		Valid(false)
+	if !e.LastModified.IsZero() {
+		it.Var("item_listed_modified", strconv.FormatInt(e.LastModified.Unix(), 10))
+	}
+	if account.Name != "" {
+		it.Var("profile", account.Name)
+	}
+
+	if a, ok := entryAction(cfg.ModifierReturn, e); ok {
+		setActionVars(it.Valid(true), a)
+	}
+
+	for _, m := range modifierActions() {
+		a, ok := entryAction(m.action, e)
+		if !ok {
+			continue
+		}
+		setActionVars(it.NewModifier(m.key).Subtitle(a.Label).Valid(true), a)
+	}
+
+	return it
+}
+
+// entryAction returns the named action if it can be mapped to a key on e.
+// Actions that change the vault are never mapped to keys.
+func entryAction(name string, e lastpass.Entry) (actions.Action, bool) {
+	a, ok := actions.Find(name)
+	if !ok || a.Kind == actions.KindChange || !a.IsAvailable(e) {
+		return actions.Action{}, false
+	}
+	return a, true
+}
+
+// totpEntries returns which entries of profile p have a TOTP secret. Finding
+// out reads every entry, so it is only done when a key is mapped to copy_totp,
+// and the result is cached until the next sync.
+func totpEntries(p lastpass.Profile) (map[string]bool, error) {
+	mapped := cfg.ModifierReturn == totpAction
+	for _, m := range modifierActions() {
+		mapped = mapped || m.action == totpAction
+	}
+	if !mapped {
+		return nil, nil
+	}
+
+	var ids []string
+	reload := func() (interface{}, error) {
+		svc := ls
+		if p.Name != profile.Name {
+			var err error
+			if svc, err = newService(ls.BinPath, p); err != nil {
+				return nil, err
+			}
+		}
+		entries, err := svc.GetEntries("", nil, nil, false)
+		if err != nil {
+			return nil, err
+		}
+		all := make([]string, 0, len(entries))
+		for _, e := range entries {
+			all = append(all, e.ID)
+		}
+		found, err := svc.EntriesWithField(all, actions.TOTPFields)
+		if err != nil {
+			return nil, err
+		}
+		for id := range found {
+			ids = append(ids, id)
+		}
+		return ids, nil
+	}
+	if err := wf.Cache.LoadOrStoreJSON(profileCacheName(totpEntriesCache, p), totpEntriesMaxAge, reload, &ids); err != nil {
+		return nil, err
+	}
+
+	totp := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		totp[id] = true
+	}
+	return totp, nil
+}
+
+type modifierAction struct {
+	key    string
+	action string
+}
+
+// modifierActions returns the configured action for each modifier key.
+func modifierActions() []modifierAction {
+	return []modifierAction{
+		{key: aw.ModCmd, action: cfg.ModifierCmd},
+		{key: aw.ModOpt, action: cfg.ModifierOpt},
+		{key: aw.ModCtrl, action: cfg.ModifierCtrl},
+		{key: aw.ModShift, action: cfg.ModifierShift},
+		{key: aw.ModFn, action: cfg.ModifierFn},
+	}
+}
+
+// setActionVars sets the variables the workflow uses to route and run an action.
+func setActionVars[T interface{ Var(k, v string) T }](v T, a actions.Action) T {
+	return v.Var("action", a.ID).
+		Var("action_kind", a.Kind).
+		Var("copy_field", a.Field).
+		Var("sensitive", strconv.FormatBool(a.Sensitive))
+}
+
 func init() {
 	listCmd.Flags().StringSliceVarP(&foldersFlag, "folders", "f", []string{}, "Filter entries by folders")
+	listCmd.Flags().StringVarP(&sortFlag, "sort", "s", "", "Sort entries by recent-used, recent-modified or name")
+	listCmd.Flags().BoolVar(&favouritesFlag, "favourites", false, "Only show pinned entries")
+	listCmd.Flags().BoolVar(&scopedFlag, "scoped", false, "Restrict entries to the active scope, falling back to --folders")
 
 	rootCmd.AddCommand(listCmd)
 }
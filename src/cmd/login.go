@@ -0,0 +1,48 @@
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+
+	aw "github.com/deanishe/awgo"
+	"github.com/rwilgaard/alfred-lastpass-search/src/pkg/actions"
+	"github.com/rwilgaard/alfred-lastpass-search/src/pkg/lastpass"
+	"github.com/spf13/cobra"
+)
+
+// askPassScript asks for the master password and multifactor codes.
+const askPassScript = "askpass.sh"
+
+var loginCmd = &cobra.Command{
+	Use:          "login",
+	Short:        "log in to LastPass",
+	SilenceUsage: true,
+	Args:         cobra.NoArgs,
+	Annotations:  map[string]string{annotationSkipLoginCheck: "true"},
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		wf.Configure(aw.TextErrors(true))
+
+		askPass, err := filepath.Abs(askPassScript)
+		if err != nil {
+			return err
+		}
+
+		res, err := ls.Login(cmd.Context(), profile.Username, lastpass.LoginOptions{
+			AskPass: askPass,
+			Trust:   true,
+		})
+		if err != nil {
+			return sendActionResult("Login", actions.Result{}, err)
+		}
+
+		msg := res.Message
+		if msg == "" {
+			msg = fmt.Sprintf("Logged in as %s.", profile.Username)
+		}
+		return sendActionResult("Login", actions.Result{Value: msg, Message: msg}, nil)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(loginCmd)
+}
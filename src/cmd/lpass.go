@@ -0,0 +1,58 @@
+package cmd
+
+import (
+	"path/filepath"
+	"strings"
+
+	"github.com/rwilgaard/alfred-lastpass-search/src/pkg/lastpass"
+)
+
+// newService creates the Service for the account of profile p, with the lpass
+// environment from the workflow configuration.
+func newService(binPath string, p lastpass.Profile) (*lastpass.Service, error) {
+	if binPath == "" {
+		binPath = "lpass"
+	}
+
+	svc, err := lastpass.NewService(binPath)
+	if err != nil {
+		return nil, err
+	}
+	svc.Env = lpassEnv(p.Home)
+	svc.SyncFile = filepath.Join(wf.CacheDir(), profileCacheName(lastSyncFile, p))
+
+	return svc, nil
+}
+
+// lpassEnv returns the environment variables for lpass set in the workflow
+// configuration, with home as LPASS_HOME.
+func lpassEnv(home string) []string {
+	var env []string
+	if home != "" {
+		env = append(env, "LPASS_HOME="+lastpass.ExpandPath(home))
+	}
+	if cfg.LpassAgentTimeout != "" {
+		env = append(env, "LPASS_AGENT_TIMEOUT="+cfg.LpassAgentTimeout)
+	}
+	if cfg.LpassDisablePinentry {
+		env = append(env, "LPASS_DISABLE_PINENTRY=1")
+	}
+	return env
+}
+
+// lpassCommand is the shell command that runs lpass with its environment, for
+// the workflow actions that run lpass themselves.
+func lpassCommand() string {
+	var parts []string
+	if len(ls.Env) > 0 {
+		parts = append(parts, "env")
+		for _, e := range ls.Env {
+			parts = append(parts, shellQuote(e))
+		}
+	}
+	return strings.Join(append(parts, shellQuote(ls.BinPath)), " ")
+}
+
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
@@ -0,0 +1,33 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	aw "github.com/deanishe/awgo"
+	"github.com/rwilgaard/alfred-lastpass-search/src/pkg/actions"
+	"github.com/spf13/cobra"
+)
+
+var moveCmd = &cobra.Command{
+	Use:          "move <item_id> <folder>",
+	Short:        "move an entry to another folder",
+	SilenceUsage: true,
+	Args:         cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		wf.Configure(aw.TextErrors(true))
+
+		itemID, folder := args[0], args[1]
+		label := "Move to " + folder
+		if err := ls.MoveEntry(cmd.Context(), itemID, folder, writeOptions()); err != nil {
+			return sendActionResult(label, actions.Result{}, err)
+		}
+
+		msg := fmt.Sprintf("%s moved to %s", itemName(itemID), strings.TrimSuffix(folder, "/"))
+		return sendActionResult(label, actions.Result{Value: msg, Message: msg}, nil)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(moveCmd)
+}
@@ -0,0 +1,178 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	aw "github.com/deanishe/awgo"
+	"github.com/rwilgaard/alfred-lastpass-search/src/pkg/actions"
+	"github.com/rwilgaard/alfred-lastpass-search/src/pkg/lastpass"
+	"github.com/rwilgaard/alfred-lastpass-search/src/pkg/util"
+	"github.com/rwilgaard/go-alfredutils/alfredutils"
+	"github.com/spf13/cobra"
+)
+
+const activeProfileFile = "active_profile"
+
+var (
+	profileCmd = &cobra.Command{
+		Use:   "profile",
+		Short: "manage LastPass account profiles",
+	}
+	profileListCmd = &cobra.Command{
+		Use:          "list",
+		Short:        "list configured profiles",
+		SilenceUsage: true,
+		Args:         cobra.RangeArgs(0, 1),
+		Annotations:  map[string]string{annotationSkipLoginCheck: "true"},
+		Run: func(_ *cobra.Command, args []string) {
+			profiles, err := allProfiles()
+			if err != nil {
+				wf.FatalError(err)
+			}
+
+			active, err := selectedProfile(profiles)
+			if err != nil {
+				wf.FatalError(err)
+			}
+
+			for _, p := range profiles {
+				title := p.Name
+				if p.Name == active.Name {
+					title += "  ✓"
+				}
+
+				state := "not logged in"
+				if svc, err := newService(ls.BinPath, p); err == nil && svc.IsLoggedIn() {
+					state = "logged in"
+				}
+				parts := []string{p.Username, state}
+				if p.Home != "" {
+					parts = append(parts, p.Home)
+				}
+
+				wf.NewItem(title).
+					Subtitle(strings.Join(parts, "  •  ")).
+					Match(fmt.Sprintf("%s %s", p.Name, p.Username)).
+					Icon(util.GetIcon("username")).
+					Arg(p.Name).
+					Valid(true)
+			}
+
+			if len(args) > 0 && args[0] != "" {
+				wf.Filter(args[0])
+			}
+
+			alfredutils.HandleFeedback(wf)
+		},
+	}
+	profileSetCmd = &cobra.Command{
+		Use:          "set <name>",
+		Short:        "switch to another account profile",
+		SilenceUsage: true,
+		Args:         cobra.ExactArgs(1),
+		Annotations:  map[string]string{annotationSkipLoginCheck: "true"},
+		RunE: func(_ *cobra.Command, args []string) error {
+			wf.Configure(aw.TextErrors(true))
+
+			name := strings.TrimSpace(args[0])
+			err := setActiveProfile(name)
+			msg := "Switched to " + name
+			return sendActionResult("Switch profile", actions.Result{Value: msg, Message: msg}, err)
+		},
+	}
+)
+
+// setActiveProfile selects the profile called name for the next commands.
+func setActiveProfile(name string) error {
+	profiles, err := allProfiles()
+	if err != nil {
+		return err
+	}
+	if _, ok := lastpass.FindProfile(profiles, name); !ok {
+		return fmt.Errorf("profile '%s' is not configured", name)
+	}
+	return wf.Data.Store(activeProfileFile, []byte(name))
+}
+
+// allProfiles returns the default profile followed by the configured ones.
+func allProfiles() ([]lastpass.Profile, error) {
+	profiles, err := lastpass.ParseProfiles(cfg.Profiles)
+	if err != nil {
+		return nil, err
+	}
+
+	def := lastpass.Profile{Name: lastpass.DefaultProfile, Username: cfg.Username, Home: cfg.LpassHome}
+	return append([]lastpass.Profile{def}, profiles...), nil
+}
+
+// currentProfile returns the profile to run the command for: the one passed
+// by an item in the profile variable, or else the selected one.
+func currentProfile() (lastpass.Profile, error) {
+	profiles, err := allProfiles()
+	if err != nil {
+		return lastpass.Profile{}, err
+	}
+
+	if p, ok := lastpass.FindProfile(profiles, os.Getenv("profile")); ok {
+		return p, nil
+	}
+	return selectedProfile(profiles)
+}
+
+// selectedProfile returns the profile chosen with profile set, or the default
+// profile if none is chosen or it is no longer configured.
+func selectedProfile(profiles []lastpass.Profile) (lastpass.Profile, error) {
+	if wf.Data.Exists(activeProfileFile) {
+		data, err := wf.Data.Load(activeProfileFile)
+		if err != nil {
+			return lastpass.Profile{}, err
+		}
+		if p, ok := lastpass.FindProfile(profiles, strings.TrimSpace(string(data))); ok {
+			return p, nil
+		}
+	}
+	return profiles[0], nil
+}
+
+// profileService is a profile with the Service that runs lpass for it.
+type profileService struct {
+	Profile lastpass.Profile
+	Service *lastpass.Service
+}
+
+// loggedInServices returns a Service for the current profile and every other
+// profile that is logged in.
+func loggedInServices() ([]profileService, error) {
+	profiles, err := allProfiles()
+	if err != nil {
+		return nil, err
+	}
+
+	var services []profileService
+	for _, p := range profiles {
+		svc := ls
+		if p.Name != profile.Name {
+			if svc, err = newService(ls.BinPath, p); err != nil {
+				return nil, err
+			}
+			if !svc.IsLoggedIn() {
+				continue
+			}
+		}
+		services = append(services, profileService{Profile: p, Service: svc})
+	}
+	return services, nil
+}
+
+// profileCacheName returns the name of a cache or data file for profile p, so
+// every account keeps its own.
+func profileCacheName(name string, p lastpass.Profile) string {
+	return p.FileName(name)
+}
+
+func init() {
+	profileCmd.AddCommand(profileListCmd, profileSetCmd)
+	rootCmd.AddCommand(profileCmd)
+}
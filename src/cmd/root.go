@@ -1,6 +1,8 @@
 package cmd
 
 import (
+	"os"
+
 	aw "github.com/deanishe/awgo"
 	"github.com/deanishe/awgo/update"
 	"github.com/rwilgaard/alfred-lastpass-search/src/pkg/lastpass"
@@ -9,31 +11,55 @@ import (
 )
 
 type workflowConfig struct {
-	ModifierReturn      string `env:"modifier_return"`
-	ModifierCmd         string `env:"modifier_cmd"`
-	ModifierOpt         string `env:"modifier_opt"`
-	ModifierCtrl        string `env:"modifier_ctrl"`
-	AllowedSymbols      string `env:"allowed_symbols"`
-	FuzzySearch         bool   `env:"fuzzy_search"`
-	IntelligentOrdering bool   `env:"intelligent_ordering"`
+	Username             string `env:"username"`
+	ModifierReturn       string `env:"modifier_return"`
+	ModifierCmd          string `env:"modifier_cmd"`
+	ModifierOpt          string `env:"modifier_opt"`
+	ModifierCtrl         string `env:"modifier_ctrl"`
+	ModifierShift        string `env:"modifier_shift"`
+	ModifierFn           string `env:"modifier_fn"`
+	AllowedSymbols       string `env:"allowed_symbols"`
+	FuzzySearch          bool   `env:"fuzzy_search"`
+	IntelligentOrdering  bool   `env:"intelligent_ordering"`
+	Scopes               string `env:"scopes"`
+	ExcludedFolders      string `env:"excluded_folders"`
+	PrivateFolders       string `env:"private_folders"`
+	LpassPath            string `env:"lpass_path"`
+	LpassHome            string `env:"lpass_home"`
+	LpassAgentTimeout    string `env:"lpass_agent_timeout"`
+	LpassDisablePinentry bool   `env:"lpass_disable_pinentry"`
+	Profiles             string `env:"profiles"`
+	SearchAllProfiles    bool   `env:"search_all_profiles"`
+	TitleTemplate        string `env:"title_template"`
+	SubtitleTemplate     string `env:"subtitle_template"`
 }
 
 const (
 	repo       = "rwilgaard/alfred-lastpass-search"
 	maxResults = 25
+	// annotationSkipLoginCheck marks commands that run without a LastPass session.
+	annotationSkipLoginCheck = "skip_login_check"
+	// annotationOptionalLpass marks commands that run even if lpass can't be found.
+	annotationOptionalLpass = "optional_lpass"
 )
 
 var (
-	wf      *aw.Workflow
-	ls      *lastpass.Service
-	cfg     = &workflowConfig{}
-	rootCmd = &cobra.Command{
+	wf        *aw.Workflow
+	ls        *lastpass.Service
+	profile   lastpass.Profile
+	templates *itemTemplates
+	cfg       = &workflowConfig{}
+	rootCmd   = &cobra.Command{
 		Use:   "lastpass-alfred",
 		Short: "lastpass-alfred is a CLI to be used by Alfred for searching in your Lastpass Vault",
 	}
 )
 
 func Execute() {
+	if isAskPass() {
+		runAskPass()
+		return
+	}
 	wf.Run(run)
 }
 
@@ -46,13 +72,29 @@ func run() {
 		wf.FatalError(err)
 	}
 
-	var err error
-	ls, err = lastpass.NewService("lpass")
+	templates = newItemTemplates(cfg.TitleTemplate, cfg.SubtitleTemplate)
+
+	binPath, err := lastpass.FindBinary(cfg.LpassPath)
+	if err != nil {
+		if !hasAnnotation(annotationOptionalLpass) {
+			wf.FatalError(err)
+		}
+		binPath = cfg.LpassPath
+	}
+
+	profile, err = currentProfile()
+	if err != nil {
+		wf.FatalError(err)
+	}
+
+	ls, err = newService(binPath, profile)
 	if err != nil {
 		wf.FatalError(err)
 	}
+	// Script filters pass the lpass command on to the terminal actions.
+	wf.Var("lpass_command", lpassCommand())
 
-	if !ls.IsLoggedIn() {
+	if !hasAnnotation(annotationSkipLoginCheck) && !ls.IsLoggedIn() {
 		wf.NewItem("You're not logged in to Lastpass.").
 			Subtitle("Press ⏎ to login.").
 			Arg("auth").
@@ -69,6 +111,12 @@ func run() {
 	}
 }
 
+// hasAnnotation reports whether the command being run has the annotation.
+func hasAnnotation(name string) bool {
+	cmd, _, err := rootCmd.Find(os.Args[1:])
+	return err == nil && cmd.Annotations[name] == "true"
+}
+
 func init() {
 	wf = aw.New(
 		aw.MaxResults(maxResults),
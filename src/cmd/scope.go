@@ -0,0 +1,149 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	aw "github.com/deanishe/awgo"
+	"github.com/rwilgaard/alfred-lastpass-search/src/pkg/lastpass"
+	"github.com/rwilgaard/alfred-lastpass-search/src/pkg/util"
+	"github.com/rwilgaard/go-alfredutils/alfredutils"
+	"github.com/spf13/cobra"
+)
+
+const activeScopeFile = "active_scope"
+
+var (
+	scopeCmd = &cobra.Command{
+		Use:   "scope",
+		Short: "manage search scopes",
+	}
+	scopeListCmd = &cobra.Command{
+		Use:          "list",
+		Short:        "list configured scopes",
+		SilenceUsage: true,
+		Args:         cobra.RangeArgs(0, 1),
+		Run: func(_ *cobra.Command, args []string) {
+			scopes, err := lastpass.ParseScopes(cfg.Scopes)
+			if err != nil {
+				wf.FatalError(err)
+			}
+
+			active, err := activeScope()
+			if err != nil {
+				wf.FatalError(err)
+			}
+
+			noneTitle := "No scope"
+			if active == nil {
+				noneTitle += "  ✓"
+			}
+			wf.NewItem(noneTitle).
+				Subtitle("Search the entire vault").
+				Match("none").
+				Arg("").
+				Valid(true)
+
+			for _, s := range scopes {
+				title := s.Name
+				if active != nil && active.Name == s.Name {
+					title += "  ✓"
+				}
+				wf.NewItem(title).
+					Subtitle(scopeSubtitle(s)).
+					Match(s.Name).
+					Icon(util.IconFolder).
+					Arg(s.Name).
+					Valid(true)
+			}
+
+			if len(args) > 0 && args[0] != "" {
+				wf.Filter(args[0])
+			}
+
+			alfredutils.HandleFeedback(wf)
+		},
+	}
+	scopeSetCmd = &cobra.Command{
+		Use:          "set [name]",
+		Short:        "set the active scope",
+		SilenceUsage: true,
+		Args:         cobra.RangeArgs(0, 1),
+		RunE: func(_ *cobra.Command, args []string) error {
+			wf.Configure(aw.TextErrors(true))
+
+			var name string
+			if len(args) > 0 {
+				name = strings.TrimSpace(args[0])
+			}
+
+			if name != "" {
+				scopes, err := lastpass.ParseScopes(cfg.Scopes)
+				if err != nil {
+					return err
+				}
+				if _, ok := lastpass.FindScope(scopes, name); !ok {
+					return fmt.Errorf("scope '%s' is not configured", name)
+				}
+			}
+
+			if err := wf.Data.Store(activeScopeFile, []byte(name)); err != nil {
+				return err
+			}
+
+			if name == "" {
+				fmt.Print("Scope cleared")
+			} else {
+				fmt.Printf("Active scope: %s", name)
+			}
+			return nil
+		},
+	}
+)
+
+// activeScope returns the selected scope, or nil if none is selected or it is no longer configured.
+func activeScope() (*lastpass.Scope, error) {
+	if !wf.Data.Exists(activeScopeFile) {
+		return nil, nil
+	}
+
+	data, err := wf.Data.Load(activeScopeFile)
+	if err != nil {
+		return nil, err
+	}
+
+	name := strings.TrimSpace(string(data))
+	if name == "" {
+		return nil, nil
+	}
+
+	scopes, err := lastpass.ParseScopes(cfg.Scopes)
+	if err != nil {
+		return nil, err
+	}
+
+	s, ok := lastpass.FindScope(scopes, name)
+	if !ok {
+		return nil, nil
+	}
+	return &s, nil
+}
+
+func scopeSubtitle(s lastpass.Scope) string {
+	parts := []string{}
+	if len(s.Include) > 0 {
+		parts = append(parts, "Include: "+strings.Join(s.Include, ", "))
+	}
+	if len(s.Exclude) > 0 {
+		parts = append(parts, "Exclude: "+strings.Join(s.Exclude, ", "))
+	}
+	if len(parts) == 0 {
+		return "No folder patterns"
+	}
+	return strings.Join(parts, "  •  ")
+}
+
+func init() {
+	scopeCmd.AddCommand(scopeListCmd, scopeSetCmd)
+	rootCmd.AddCommand(scopeCmd)
+}
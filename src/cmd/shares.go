@@ -0,0 +1,286 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	aw "github.com/deanishe/awgo"
+	"github.com/rwilgaard/alfred-lastpass-search/src/pkg/actions"
+	"github.com/rwilgaard/alfred-lastpass-search/src/pkg/lastpass"
+	"github.com/rwilgaard/alfred-lastpass-search/src/pkg/util"
+	"github.com/rwilgaard/go-alfredutils/alfredutils"
+	"github.com/spf13/cobra"
+)
+
+const (
+	sharePermissionsCache  = "share_permissions.json"
+	sharePermissionsMaxAge = 24 * time.Hour
+)
+
+// shareAccessOption is one of the permission sets offered for a member.
+type shareAccessOption struct {
+	Label  string
+	Access lastpass.ShareAccess
+}
+
+var shareAccessOptions = []shareAccessOption{
+	{Label: "Read-write", Access: lastpass.ShareAccess{}},
+	{Label: "Read-write, passwords hidden", Access: lastpass.ShareAccess{HidePasswords: true}},
+	{Label: "Read-only", Access: lastpass.ShareAccess{ReadOnly: true}},
+	{Label: "Read-only, passwords hidden", Access: lastpass.ShareAccess{ReadOnly: true, HidePasswords: true}},
+	{Label: "Admin", Access: lastpass.ShareAccess{Admin: true}},
+}
+
+var (
+	shareReadOnlyFlag      bool
+	shareAdminFlag         bool
+	shareHidePasswordsFlag bool
+	sharesCmd              = &cobra.Command{
+		Use:   "shares",
+		Short: "manage the members of shared folders",
+	}
+	sharesFoldersCmd = &cobra.Command{
+		Use:          "folders [query]",
+		Short:        "list shared folders",
+		SilenceUsage: true,
+		Args:         cobra.RangeArgs(0, 1),
+		Run: func(cmd *cobra.Command, args []string) {
+			folders, err := ls.GetFolders()
+			if err != nil {
+				wf.FatalError(err)
+			}
+
+			lastpass.SetSharePermissions(folders, sharePermissions(cmd.Context(), ls, folders))
+			for _, f := range folders {
+				share := f.Share()
+				if !f.Shared || strings.TrimSuffix(f.Name, "/") != share {
+					continue
+				}
+				wf.NewItem(share).
+					Subtitle(f.Subtitle(time.Now())).
+					Match(share).
+					UID(share).
+					Icon(util.IconFolder).
+					Var("share", share).
+					Valid(true)
+			}
+
+			if len(args) > 0 {
+				wf.Filter(args[0])
+			}
+			wf.WarnEmpty("No shared folders found", "Try a different query")
+			alfredutils.HandleFeedback(wf)
+		},
+	}
+	sharesMembersCmd = &cobra.Command{
+		Use:          "members <share> [query]",
+		Short:        "list the members of a shared folder",
+		SilenceUsage: true,
+		Args:         cobra.RangeArgs(1, 2),
+		Run: func(cmd *cobra.Command, args []string) {
+			share := strings.TrimSuffix(args[0], "/")
+			var query string
+			if len(args) > 1 {
+				query = strings.TrimSpace(args[1])
+			}
+
+			users, err := ls.GetShareUsers(cmd.Context(), share)
+			if err != nil {
+				wf.FatalError(err)
+			}
+
+			for _, u := range users {
+				wf.NewItem(u.Name).
+					Subtitle(memberSubtitle(u)).
+					Match(fmt.Sprintf("%s %s", u.Name, u.Username)).
+					UID(u.Username).
+					Icon(util.GetIcon("username")).
+					Var("share", share).
+					Var("member", u.Username).
+					Var("share_action", "set").
+					Var("confirm_title", fmt.Sprintf("Change %s in %s?", u.Username, share)).
+					Valid(true).
+					NewModifier(aw.ModCmd).
+					Subtitle("Remove from "+share).
+					Var("share", share).
+					Var("member", u.Username).
+					Valid(true)
+			}
+
+			if query != "" {
+				wf.Filter(query)
+			}
+
+			if strings.Contains(query, "@") && lastpass.SharePermission(users, query) == "" {
+				wf.NewItem(fmt.Sprintf("Add %s", query)).
+					Subtitle("Press ⏎ to choose permissions and invite to "+share).
+					Icon(util.GetIcon("username")).
+					Var("share", share).
+					Var("member", query).
+					Var("share_action", "add").
+					Var("confirm_title", fmt.Sprintf("Add %s to %s?", query, share)).
+					Valid(true)
+			}
+
+			wf.WarnEmpty("No members found", "Type an email address to add a member")
+			alfredutils.HandleFeedback(wf)
+		},
+	}
+	sharesPermissionsCmd = &cobra.Command{
+		Use:          "permissions [query]",
+		Short:        "list the permissions a member can have",
+		SilenceUsage: true,
+		Args:         cobra.RangeArgs(0, 1),
+		Run: func(_ *cobra.Command, args []string) {
+			for _, o := range shareAccessOptions {
+				wf.NewItem(o.Label).
+					Match(o.Label).
+					Var("read_only", strconv.FormatBool(o.Access.ReadOnly)).
+					Var("admin", strconv.FormatBool(o.Access.Admin)).
+					Var("hide_passwords", strconv.FormatBool(o.Access.HidePasswords)).
+					Var("access_label", o.Label).
+					Valid(true)
+			}
+
+			if len(args) > 0 {
+				wf.Filter(args[0])
+			}
+			alfredutils.HandleFeedback(wf)
+		},
+	}
+	sharesAddCmd = &cobra.Command{
+		Use:          "add <share> <username>",
+		Short:        "add a member to a shared folder",
+		SilenceUsage: true,
+		Args:         cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			wf.Configure(aw.TextErrors(true))
+
+			share, username := strings.TrimSuffix(args[0], "/"), args[1]
+			err := ls.AddShareUser(cmd.Context(), share, username, shareAccessFlags())
+			return sendShareResult("Add member", fmt.Sprintf("%s added to %s", username, share), err)
+		},
+	}
+	sharesSetCmd = &cobra.Command{
+		Use:          "set <share> <username>",
+		Short:        "change the permissions of a member of a shared folder",
+		SilenceUsage: true,
+		Args:         cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			wf.Configure(aw.TextErrors(true))
+
+			share, username := strings.TrimSuffix(args[0], "/"), args[1]
+			err := ls.UpdateShareUser(cmd.Context(), share, username, shareAccessFlags())
+			return sendShareResult("Change member", fmt.Sprintf("Permissions of %s in %s changed", username, share), err)
+		},
+	}
+	sharesRemoveCmd = &cobra.Command{
+		Use:          "remove <share> <username>",
+		Short:        "remove a member from a shared folder",
+		SilenceUsage: true,
+		Args:         cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			wf.Configure(aw.TextErrors(true))
+
+			share, username := strings.TrimSuffix(args[0], "/"), args[1]
+			err := ls.RemoveShareUser(cmd.Context(), share, username)
+			return sendShareResult("Remove member", fmt.Sprintf("%s removed from %s", username, share), err)
+		},
+	}
+)
+
+// memberSubtitle describes the permissions of a member of a shared folder.
+func memberSubtitle(u lastpass.ShareUser) string {
+	parts := []string{u.Username, u.Permission()}
+	if u.HidePasswords {
+		parts = append(parts, "passwords hidden")
+	}
+	if !u.Accepted {
+		parts = append(parts, "invitation pending")
+	}
+	if u.OutsideEnterprise {
+		parts = append(parts, "outside enterprise")
+	}
+	return strings.Join(parts, "  •  ")
+}
+
+// sharePermissions returns our permission in each shared folder. Looking these
+// up takes one lpass call per share, so only the shares view does it, and the
+// result is cached.
+func sharePermissions(ctx context.Context, ls *lastpass.Service, folders []lastpass.Folder) map[string]string {
+	perms := map[string]string{}
+	reload := func() (interface{}, error) {
+		fresh := map[string]string{}
+		for _, f := range folders {
+			share := f.Share()
+			if _, done := fresh[share]; !f.Shared || done {
+				continue
+			}
+			users, err := ls.GetShareUsers(ctx, share)
+			if err != nil {
+				log.Printf("Error getting users of %s: %v", share, err)
+				continue
+			}
+			fresh[share] = lastpass.SharePermission(users, profile.Username)
+		}
+		return fresh, nil
+	}
+
+	if err := wf.Cache.LoadOrStoreJSON(profileCacheName(sharePermissionsCache, profile), sharePermissionsMaxAge, reload, &perms); err != nil {
+		log.Printf("Error loading share permissions: %v", err)
+	}
+	return perms
+}
+
+// cachedSharePermissions returns the share permissions last looked up by the
+// shares view, however old, without looking them up again.
+func cachedSharePermissions() map[string]string {
+	perms := map[string]string{}
+	name := profileCacheName(sharePermissionsCache, profile)
+	if !wf.Cache.Exists(name) {
+		return perms
+	}
+	if err := wf.Cache.LoadJSON(name, &perms); err != nil {
+		log.Printf("Error loading share permissions: %v", err)
+	}
+	return perms
+}
+
+func shareAccessFlags() lastpass.ShareAccess {
+	return lastpass.ShareAccess{
+		ReadOnly:      shareReadOnlyFlag,
+		Admin:         shareAdminFlag,
+		HidePasswords: shareHidePasswordsFlag,
+	}
+}
+
+// sendShareResult reports the outcome of a membership change. Our own cached
+// share permissions may have changed with it.
+func sendShareResult(label string, msg string, err error) error {
+	if err != nil {
+		return sendActionResult(label, actions.Result{}, err)
+	}
+	if err := wf.Cache.Store(profileCacheName(sharePermissionsCache, profile), nil); err != nil {
+		log.Printf("error clearing share permissions cache: %v", err)
+	}
+	return sendActionResult(label, actions.Result{Value: msg, Message: msg}, nil)
+}
+
+func init() {
+	for _, c := range []*cobra.Command{sharesAddCmd, sharesSetCmd} {
+		c.Flags().BoolVar(&shareReadOnlyFlag, "read-only", false, "Member can't change entries")
+		c.Flags().BoolVar(&shareAdminFlag, "admin", false, "Member can manage the shared folder")
+		c.Flags().BoolVar(&shareHidePasswordsFlag, "hide-passwords", false, "Member can't see passwords")
+	}
+	sharesCmd.AddCommand(sharesFoldersCmd)
+	sharesCmd.AddCommand(sharesMembersCmd)
+	sharesCmd.AddCommand(sharesPermissionsCmd)
+	sharesCmd.AddCommand(sharesAddCmd)
+	sharesCmd.AddCommand(sharesSetCmd)
+	sharesCmd.AddCommand(sharesRemoveCmd)
+	rootCmd.AddCommand(sharesCmd)
+}
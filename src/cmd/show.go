@@ -2,10 +2,13 @@ package cmd
 
 import (
 	"fmt"
+	"log"
 	"os"
 	"slices"
+	"strconv"
 	"strings"
 
+	aw "github.com/deanishe/awgo"
 	"github.com/rwilgaard/alfred-lastpass-search/src/pkg/util"
 	"github.com/rwilgaard/go-alfredutils/alfredutils"
 	"github.com/spf13/cobra"
@@ -16,7 +19,7 @@ var showCmd = &cobra.Command{
 	Short:        "show entry details",
 	SilenceUsage: true,
 	Args:         cobra.ExactArgs(1),
-	RunE: func(_ *cobra.Command, args []string) error {
+	RunE: func(cmd *cobra.Command, args []string) error {
 		itemID := args[0]
 
 		keys, details, err := ls.GetDetails(itemID)
@@ -37,6 +40,14 @@ var showCmd = &cobra.Command{
 
 		fullname := fmt.Sprintf("%s/%s", os.Getenv("item_folder"), os.Getenv("item_name"))
 
+		// Changes made from this view are refused if the entry is modified
+		// elsewhere in the meantime.
+		if modified, err := ls.LastModified(cmd.Context(), itemID, false); err == nil {
+			wf.Var("item_modified", strconv.FormatInt(modified.Unix(), 10))
+		} else {
+			log.Printf("error reading last modification time of %s: %v", itemID, err)
+		}
+
 		wf.NewItem("Go back").
 			Icon(util.IconBack).
 			Arg("go_back").
@@ -63,27 +74,62 @@ var showCmd = &cobra.Command{
 				sub = strings.Repeat("•", 32)
 				sensitive = "true"
 			}
+			var it *aw.Item
 			if key == "Notes" {
-				wf.NewItem(key).
+				it = wf.NewItem(key).
 					Icon(util.GetIcon(key)).
 					Subtitle("Press ⏎ to show notes").
 					Arg("notes").
 					Var("sensitive", sensitive).
 					Valid(true)
-				continue
+			} else {
+				it = wf.NewItem(key).
+					Icon(util.GetIcon(key)).
+					Subtitle(sub).
+					Arg(value).
+					Var("sensitive", sensitive).
+					Var("field", key).
+					Valid(true)
 			}
-			wf.NewItem(key).
-				Icon(util.GetIcon(key)).
-				Subtitle(sub).
-				Arg(value).
+			it.Opt().
+				Subtitle("Change "+key).
+				Icon(util.IconEdit).
+				Arg("change").
+				Var("change_field", key).
 				Var("sensitive", sensitive).
-				Var("field", key).
 				Valid(true)
 		}
 
-		wf.NewItem("Edit entry").
-			Icon(util.IconEdit).
-			Arg("edit").
+		favs, err := loadFavourites(profile)
+		if err != nil {
+			wf.FatalError(err)
+		}
+
+		pinTitle := "Pin entry"
+		if favs.IsPinned(itemID) {
+			pinTitle = "Unpin entry"
+		}
+
+		wf.NewItem(pinTitle).
+			Icon(util.IconPin).
+			Arg("pin").
+			Valid(true)
+
+		wf.NewItem("Add alias").
+			Icon(util.IconAlias).
+			Subtitle("Jump straight to this entry by typing a short alias in lp").
+			Arg("alias").
+			Valid(true)
+
+		wf.NewItem("Clone entry").
+			Icon(util.IconClone).
+			Subtitle("Copy this entry under a new name, optionally with a new password").
+			Arg("clone").
+			Valid(true)
+
+		wf.NewItem("Move to folder…").
+			Icon(util.IconFolder).
+			Arg("move").
 			Valid(true)
 
 		deleteMsg := fmt.Sprintf(`Are you sure you want to delete this entry?
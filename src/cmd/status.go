@@ -0,0 +1,163 @@
+package cmd
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	aw "github.com/deanishe/awgo"
+	"github.com/rwilgaard/alfred-lastpass-search/src/pkg/actions"
+	"github.com/rwilgaard/alfred-lastpass-search/src/pkg/lastpass"
+	"github.com/rwilgaard/alfred-lastpass-search/src/pkg/util"
+	"github.com/rwilgaard/go-alfredutils/alfredutils"
+	"github.com/spf13/cobra"
+)
+
+// lastSyncFile records the time of the last successful sync in the cache dir.
+const lastSyncFile = "last_sync"
+
+var (
+	statusCmd = &cobra.Command{
+		Use:          "status",
+		Short:        "show the LastPass session, agent and sync state",
+		SilenceUsage: true,
+		Args:         cobra.NoArgs,
+		Annotations:  map[string]string{annotationSkipLoginCheck: "true"},
+		Run: func(cmd *cobra.Command, _ []string) {
+			st, err := ls.Status(cmd.Context())
+			if err != nil {
+				wf.FatalError(err)
+			}
+			now := time.Now()
+
+			if !st.LoggedIn {
+				wf.NewItem("Not logged in").
+					Subtitle("Press ⏎ to login").
+					Icon(util.IconWarn).
+					Arg("auth").
+					Valid(true)
+			} else {
+				wf.NewItem("Logged in as " + st.Account).
+					Subtitle("LastPass session").
+					Icon(util.GetIcon("username"))
+			}
+
+			wf.NewItem("Agent timeout: " + agentTimeoutText(st.AgentTimeout)).
+				Subtitle("How long the master password is kept. Set with LPASS_AGENT_TIMEOUT").
+				Icon(util.IconLock)
+			wf.NewItem("Local vault updated " + util.RelativeTime(st.BlobModified, now)).
+				Subtitle(timestamp(st.BlobModified)).
+				Icon(util.IconSync)
+			wf.NewItem("Last sync " + util.RelativeTime(st.LastSync, now)).
+				Subtitle(timestamp(st.LastSync)).
+				Icon(util.IconSync)
+
+			if st.LoggedIn {
+				wf.NewItem("Sync now").
+					Subtitle("Fetch the vault from LastPass").
+					Icon(util.IconSync).
+					Arg("sync").
+					Valid(true)
+				wf.NewItem("Lock").
+					Subtitle("Forget the master password, but stay logged in").
+					Icon(util.IconLock).
+					Arg("lock").
+					Valid(true)
+				wf.NewItem("Log out").
+					Subtitle("End the session and remove the local vault").
+					Icon(util.IconLogout).
+					Arg("logout").
+					Valid(true)
+			}
+
+			alfredutils.HandleFeedback(wf)
+		},
+	}
+	syncCmd = &cobra.Command{
+		Use:          "sync",
+		Short:        "fetch the vault from LastPass",
+		SilenceUsage: true,
+		Args:         cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			wf.Configure(aw.TextErrors(true))
+
+			res, err := ls.Sync(cmd.Context())
+			if err == nil {
+				if err := wf.Cache.Store(profileCacheName(totpEntriesCache, profile), nil); err != nil {
+					log.Printf("error clearing TOTP cache: %v", err)
+				}
+				if err = saveSnapshot(res.Entries); err != nil {
+					err = fmt.Errorf("error saving snapshot: %w", err)
+				}
+			}
+			summary := syncSummary(res)
+			return sendActionResult("Sync", actions.Result{Value: summary, Message: summary}, err)
+		},
+	}
+	logoutCmd = &cobra.Command{
+		Use:          "logout",
+		Short:        "log out of LastPass",
+		SilenceUsage: true,
+		Args:         cobra.NoArgs,
+		Annotations:  map[string]string{annotationSkipLoginCheck: "true"},
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			wf.Configure(aw.TextErrors(true))
+
+			err := ls.Logout(cmd.Context())
+			return sendActionResult("Logout", actions.Result{Value: "Logged out", Message: "Logged out"}, err)
+		},
+	}
+	lockCmd = &cobra.Command{
+		Use:          "lock",
+		Short:        "forget the master password until the next login",
+		SilenceUsage: true,
+		Args:         cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			wf.Configure(aw.TextErrors(true))
+
+			err := ls.Lock(cmd.Context())
+			return sendActionResult("Lock", actions.Result{Value: "LastPass locked", Message: "LastPass locked"}, err)
+		},
+	}
+)
+
+// syncSummary describes a sync, e.g. "Synced in 1.2s: 2 added, 1 modified".
+func syncSummary(res lastpass.SyncResult) string {
+	var changes []string
+	for _, c := range []struct {
+		n    int
+		verb string
+	}{{res.Added, "added"}, {res.Removed, "removed"}, {res.Modified, "modified"}} {
+		if c.n > 0 {
+			changes = append(changes, fmt.Sprintf("%d %s", c.n, c.verb))
+		}
+	}
+
+	summary := "Synced in " + res.Duration.Round(100*time.Millisecond).String()
+	if len(changes) == 0 {
+		return summary + ", no changes"
+	}
+	return summary + ": " + strings.Join(changes, ", ")
+}
+
+func agentTimeoutText(d time.Duration) string {
+	if d == 0 {
+		return "until logout"
+	}
+	return d.String()
+}
+
+func timestamp(t time.Time) string {
+	if t.IsZero() {
+		return "Unknown"
+	}
+	return t.Local().Format("2006-01-02 15:04")
+}
+
+func init() {
+	rootCmd.AddCommand(statusCmd)
+	rootCmd.AddCommand(syncCmd)
+	rootCmd.AddCommand(logoutCmd)
+	rootCmd.AddCommand(lockCmd)
+}
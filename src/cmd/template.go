@@ -0,0 +1,110 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"net/url"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/rwilgaard/alfred-lastpass-search/src/pkg/lastpass"
+	"github.com/rwilgaard/alfred-lastpass-search/src/pkg/util"
+)
+
+const (
+	defaultTitleTemplate    = "{{.Name}}"
+	defaultSubtitleTemplate = "{{.Folder}}  •  ID: {{.ID}}"
+)
+
+// itemData is the data model available to the title and subtitle templates.
+// The password is deliberately left out.
+type itemData struct {
+	ID              string
+	Name            string
+	Folder          string
+	FolderPath      string
+	URL             string
+	Domain          string
+	Username        string
+	LastModified    time.Time
+	LastUsed        time.Time
+	LastModifiedAgo string
+	LastUsedAgo     string
+	Pinned          bool
+}
+
+// itemTemplates renders list item titles and subtitles from the workflow configuration.
+type itemTemplates struct {
+	title    *template.Template
+	subtitle *template.Template
+	errs     []error
+}
+
+// newItemTemplates parses the configured templates. A template that fails to
+// parse is replaced by the default, and the error is kept so it can be shown.
+func newItemTemplates(title string, subtitle string) *itemTemplates {
+	t := &itemTemplates{}
+	t.title = t.parse("title", title, defaultTitleTemplate)
+	t.subtitle = t.parse("subtitle", subtitle, defaultSubtitleTemplate)
+	return t
+}
+
+func (t *itemTemplates) parse(name string, text string, fallback string) *template.Template {
+	if strings.TrimSpace(text) == "" {
+		text = fallback
+	}
+
+	tmpl, err := template.New(name).Option("missingkey=error").Parse(text)
+	if err == nil {
+		// Execute once against an empty entry to catch unknown fields early.
+		err = tmpl.Execute(&bytes.Buffer{}, itemData{})
+	}
+	if err != nil {
+		t.errs = append(t.errs, fmt.Errorf("invalid %s template: %w", name, err))
+		return template.Must(template.New(name).Parse(fallback))
+	}
+	return tmpl
+}
+
+// Title renders the title for an entry.
+func (t *itemTemplates) Title(e lastpass.Entry, pinned bool, now time.Time) string {
+	return render(t.title, newItemData(e, pinned, now), e.Name)
+}
+
+// Subtitle renders the subtitle for an entry.
+func (t *itemTemplates) Subtitle(e lastpass.Entry, pinned bool, now time.Time) string {
+	return render(t.subtitle, newItemData(e, pinned, now), fmt.Sprintf("%s  •  ID: %s", e.Folder, e.ID))
+}
+
+func render(tmpl *template.Template, data itemData, fallback string) string {
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		log.Printf("Error rendering %s template for %s: %v", tmpl.Name(), data.ID, err)
+		return fallback
+	}
+	return buf.String()
+}
+
+func newItemData(e lastpass.Entry, pinned bool, now time.Time) itemData {
+	var domain string
+	if u, err := url.Parse(e.URL); err == nil {
+		domain = u.Hostname()
+	}
+
+	return itemData{
+		ID:              e.ID,
+		Name:            e.Name,
+		Folder:          e.Folder,
+		FolderPath:      e.FolderPath(),
+		URL:             e.URL,
+		Domain:          domain,
+		Username:        e.Username,
+		LastModified:    e.LastModified,
+		LastUsed:        e.LastUsed,
+		LastModifiedAgo: util.RelativeTime(e.LastModified, now),
+		LastUsedAgo:     util.RelativeTime(e.LastUsed, now),
+		Pinned:          pinned,
+	}
+}
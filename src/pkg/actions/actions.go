@@ -0,0 +1,71 @@
+// Package actions is the registry of actions that can be mapped to the
+// modifier keys of list items.
+package actions
+
+import (
+	"context"
+	"strings"
+
+	"github.com/rwilgaard/alfred-lastpass-search/src/pkg/lastpass"
+)
+
+// Kinds of actions. The workflow routes an action's output by its kind.
+const (
+	KindCopy    = "copy"
+	KindOpen    = "open"
+	KindDetails = "details"
+	// KindChange actions change the vault and only report a message.
+	KindChange = "change"
+)
+
+// Action is something that can be done with an entry.
+type Action struct {
+	ID    string
+	Label string
+	Kind  string
+	// Field names the value an action copies, e.g. in notifications.
+	Field string
+	// Sensitive actions copy secrets and use a transient clipboard.
+	Sensitive bool
+	// Available reports whether the action can be used on the entry.
+	Available func(e lastpass.Entry) bool
+	// Execute runs the action.
+	Execute func(ctx context.Context, ls *lastpass.Service, e lastpass.Entry) (Result, error)
+}
+
+// Result is the outcome of an action.
+type Result struct {
+	// Value is the output of the action, such as the value to copy or the URL to open.
+	Value string
+	// Message describes what was done, for notifications.
+	Message string
+}
+
+var registry []Action
+
+// Register adds an action to the registry.
+func Register(a Action) {
+	registry = append(registry, a)
+}
+
+// All returns every registered action in registration order.
+func All() []Action {
+	return registry
+}
+
+// Find returns the action with the given ID or label. Labels are accepted so
+// that configuration from before the registry keeps working.
+func Find(name string) (Action, bool) {
+	name = strings.TrimSpace(name)
+	for _, a := range registry {
+		if strings.EqualFold(a.ID, name) || strings.EqualFold(a.Label, name) {
+			return a, true
+		}
+	}
+	return Action{}, false
+}
+
+// IsAvailable reports whether a can be used on e.
+func (a Action) IsAvailable(e lastpass.Entry) bool {
+	return a.Available == nil || a.Available(e)
+}
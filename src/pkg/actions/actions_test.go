@@ -0,0 +1,244 @@
+package actions
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"testing"
+	"time"
+
+	"github.com/rwilgaard/alfred-lastpass-search/src/pkg/lastpass"
+)
+
+// TestHelperProcess isn't a real test. It simulates the lpass binary for
+// tests that replace ExecCommand.
+func TestHelperProcess(t *testing.T) {
+	if os.Getenv("GO_WANT_HELPER_PROCESS") != "1" {
+		return
+	}
+	defer os.Exit(0)
+
+	_, _ = fmt.Fprint(os.Stdout, os.Getenv("MOCK_STDOUT"))
+	_, _ = fmt.Fprint(os.Stderr, os.Getenv("MOCK_STDERR"))
+	if os.Getenv("MOCK_EXIT_CODE") != "0" {
+		os.Exit(1)
+	}
+}
+
+func mockService(t *testing.T, stdout string, stderr string, exitCode int) *lastpass.Service {
+	t.Helper()
+	return &lastpass.Service{
+		BinPath: "lpass",
+		ExecCommand: func(cmdPath string, args ...string) *exec.Cmd {
+			cs := append([]string{"-test.run=TestHelperProcess", "--", cmdPath}, args...)
+			cmd := exec.Command(os.Args[0], cs...)
+			cmd.Env = []string{
+				"GO_WANT_HELPER_PROCESS=1",
+				"MOCK_STDOUT=" + stdout,
+				"MOCK_STDERR=" + stderr,
+				fmt.Sprintf("MOCK_EXIT_CODE=%d", exitCode),
+			}
+			return cmd
+		},
+	}
+}
+
+func TestExecute(t *testing.T) {
+	// Writes check the entry against the time it was read.
+	entry := lastpass.Entry{ID: "123", Name: "Example", LastModified: time.Unix(1700000000, 0), LastModifiedPrecision: time.Second}
+
+	testCases := []struct {
+		name         string
+		id           string
+		mockStdout   string
+		mockStderr   string
+		mockExitCode int
+		want         Result
+		wantErr      error
+	}{
+		{
+			name:       "Copy password",
+			id:         "copy_password",
+			mockStdout: "s3cret\n",
+			want:       Result{Value: "s3cret", Message: "Password copied!"},
+		},
+		{
+			name: "Copy ID without lpass",
+			id:   "copy_id",
+			want: Result{Value: "123", Message: "ID copied!"},
+		},
+		{
+			name:       "Open URL",
+			id:         "open_url",
+			mockStdout: "https://example.com\n",
+			want:       Result{Value: "https://example.com"},
+		},
+		{
+			name:       "Delete entry",
+			id:         "delete_entry",
+			mockStdout: `[{"id": "123", "last_modified_gmt": "1700000000"}]`,
+			want:       Result{Message: "Example deleted!"},
+		},
+		{
+			name:       "Copy notes",
+			id:         "copy_notes",
+			mockStdout: "Door code 1234\n",
+			want:       Result{Value: "Door code 1234", Message: "Notes copied!"},
+		},
+		{
+			name:    "Copy notes of an entry without notes",
+			id:      "copy_notes",
+			wantErr: errNoNotes,
+		},
+		{
+			name:         "Copy username of a deleted entry",
+			id:           "copy_username",
+			mockStderr:   "Error: Could not find specified account '123'.",
+			mockExitCode: 1,
+			wantErr:      lastpass.ErrNotFound,
+		},
+		{
+			name:         "Delete while logged out",
+			id:           "delete_entry",
+			mockStderr:   "Error: Could not find decryption key. Perhaps you need to login with `lpass login`.",
+			mockExitCode: 1,
+			wantErr:      lastpass.ErrNotLoggedIn,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			a, ok := Find(tt.id)
+			if !ok {
+				t.Fatalf("Find(%q) found nothing", tt.id)
+			}
+
+			got, err := a.Execute(context.Background(), mockService(t, tt.mockStdout, tt.mockStderr, tt.mockExitCode), entry)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("Execute() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Execute() unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Execute() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFind(t *testing.T) {
+	testCases := []struct {
+		name   string
+		wantID string
+		wantOK bool
+	}{
+		{name: "copy_password", wantID: "copy_password", wantOK: true},
+		{name: "Copy Password", wantID: "copy_password", wantOK: true},
+		{name: "show details", wantID: "show_details", wantOK: true},
+		{name: "Open URL", wantID: "open_url", wantOK: true},
+		{name: "Launch Rockets", wantOK: false},
+		{name: "", wantOK: false},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			a, ok := Find(tt.name)
+			if ok != tt.wantOK {
+				t.Fatalf("Find(%q) ok = %v, want %v", tt.name, ok, tt.wantOK)
+			}
+			if ok && a.ID != tt.wantID {
+				t.Errorf("Find(%q) = %s, want %s", tt.name, a.ID, tt.wantID)
+			}
+		})
+	}
+}
+
+func TestAvailability(t *testing.T) {
+	entry := lastpass.Entry{ID: "1", Name: "Note", URL: "http://sn"}
+
+	testCases := []struct {
+		id   string
+		want bool
+	}{
+		{id: "copy_password", want: false},
+		{id: "copy_username", want: false},
+		{id: "copy_url", want: false},
+		{id: "open_url", want: false},
+		{id: "copy_id", want: true},
+		{id: "copy_notes", want: true},
+		{id: "copy_totp", want: false},
+		{id: "show_details", want: true},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.id, func(t *testing.T) {
+			a, ok := Find(tt.id)
+			if !ok {
+				t.Fatalf("Find(%q) found nothing", tt.id)
+			}
+			if got := a.IsAvailable(entry); got != tt.want {
+				t.Errorf("%s.IsAvailable() = %v, want %v", tt.id, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTOTP(t *testing.T) {
+	// RFC 6238 test vectors. The SHA1 secret is "12345678901234567890", the
+	// others repeat it to 32 and 64 bytes.
+	const secret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+	const secret256 = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQGEZA"
+	const secret512 = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQGEZDGNA"
+	rfcURI := func(secret, algorithm string) string {
+		return "otpauth://totp/RFC?secret=" + secret + "&digits=8&algorithm=" + algorithm
+	}
+
+	testCases := []struct {
+		name   string
+		secret string
+		time   int64
+		want   string
+	}{
+		{name: "RFC vector 59", secret: secret, time: 59, want: "287082"},
+		{name: "RFC vector 1111111109", secret: secret, time: 1111111109, want: "081804"},
+		{name: "Lowercase with spaces", secret: "gezd gnbv gy3t qojq gezd gnbv gy3t qojq", time: 1234567890, want: "005924"},
+		{name: "otpauth URI", secret: "otpauth://totp/Example:jane?secret=" + secret + "&issuer=Example", time: 2000000000, want: "279037"},
+		{name: "SHA1 with 8 digits", secret: rfcURI(secret, "SHA1"), time: 1111111109, want: "07081804"},
+		{name: "SHA256 vector 59", secret: rfcURI(secret256, "SHA256"), time: 59, want: "46119246"},
+		{name: "SHA256 vector 1111111109", secret: rfcURI(secret256, "SHA256"), time: 1111111109, want: "68084774"},
+		{name: "SHA256 vector 20000000000", secret: rfcURI(secret256, "sha256"), time: 20000000000, want: "77737706"},
+		{name: "SHA512 vector 59", secret: rfcURI(secret512, "SHA512"), time: 59, want: "90693936"},
+		{name: "SHA512 vector 1111111109", secret: rfcURI(secret512, "SHA512"), time: 1111111109, want: "25091201"},
+		{name: "SHA512 vector 20000000000", secret: rfcURI(secret512, "SHA512"), time: 20000000000, want: "47863826"},
+		{name: "60 second period", secret: "otpauth://totp/x?secret=" + secret + "&period=60", time: 119, want: "287082"},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := TOTP(tt.secret, time.Unix(tt.time, 0))
+			if err != nil {
+				t.Fatalf("TOTP() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("TOTP() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+
+	for _, bad := range []string{
+		"not base32!",
+		"otpauth://totp/x?secret=" + secret + "&algorithm=MD5",
+		"otpauth://totp/x?secret=" + secret + "&digits=0",
+		"otpauth://totp/x?secret=" + secret + "&period=-30",
+	} {
+		if _, err := TOTP(bad, time.Now()); err == nil {
+			t.Errorf("TOTP(%q) expected an error, got nil", bad)
+		}
+	}
+}
@@ -0,0 +1,178 @@
+package actions
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/rwilgaard/alfred-lastpass-search/src/pkg/lastpass"
+)
+
+var errNoNotes = errors.New("entry has no notes")
+
+// TOTPFields are the field names checked, in order, for a TOTP secret.
+var TOTPFields = []string{"totp", "totp secret", "otp", "otp secret", "2fa"}
+
+func init() {
+	Register(Action{
+		ID:        "copy_password",
+		Label:     "Copy Password",
+		Kind:      KindCopy,
+		Field:     "Password",
+		Sensitive: true,
+		Available: func(e lastpass.Entry) bool { return e.Password != "" },
+		Execute:   copyField("password", "Password"),
+	})
+	Register(Action{
+		ID:        "copy_username",
+		Label:     "Copy Username",
+		Kind:      KindCopy,
+		Field:     "Username",
+		Available: func(e lastpass.Entry) bool { return e.Username != "" },
+		Execute:   copyField("username", "Username"),
+	})
+	Register(Action{
+		ID:    "copy_id",
+		Label: "Copy ID",
+		Kind:  KindCopy,
+		Field: "ID",
+		Execute: func(_ context.Context, _ *lastpass.Service, e lastpass.Entry) (Result, error) {
+			return Result{Value: e.ID, Message: "ID copied!"}, nil
+		},
+	})
+	Register(Action{
+		ID:        "copy_url",
+		Label:     "Copy URL",
+		Kind:      KindCopy,
+		Field:     "URL",
+		Available: hasURL,
+		Execute:   copyField("url", "URL"),
+	})
+	Register(Action{
+		ID:        "open_url",
+		Label:     "Open URL",
+		Kind:      KindOpen,
+		Field:     "URL",
+		Available: hasURL,
+		Execute: func(_ context.Context, ls *lastpass.Service, e lastpass.Entry) (Result, error) {
+			url, err := ls.GetField(e.ID, "url")
+			return Result{Value: url}, err
+		},
+	})
+	Register(Action{
+		ID:        "copy_notes",
+		Label:     "Copy Notes",
+		Kind:      KindCopy,
+		Field:     "Notes",
+		Sensitive: true,
+		// Listed entries don't carry their notes, so this is always offered
+		// and reports entries without notes when run.
+		Available: func(lastpass.Entry) bool { return true },
+		Execute:   copyNotes,
+	})
+	Register(Action{
+		ID:        "copy_totp",
+		Label:     "Copy TOTP",
+		Kind:      KindCopy,
+		Field:     "TOTP code",
+		Sensitive: true,
+		Available: func(e lastpass.Entry) bool { return e.HasTOTP },
+		Execute:   copyTOTP,
+	})
+	Register(Action{
+		ID:    "show_details",
+		Label: "Show Details",
+		Kind:  KindDetails,
+		Execute: func(_ context.Context, _ *lastpass.Service, e lastpass.Entry) (Result, error) {
+			return Result{Value: e.ID}, nil
+		},
+	})
+	Register(Action{
+		ID:    "delete_entry",
+		Label: "Delete Entry",
+		Kind:  KindChange,
+		Execute: func(ctx context.Context, ls *lastpass.Service, e lastpass.Entry) (Result, error) {
+			if err := ls.DeleteEntry(ctx, e.ID, e.WriteOptions()); err != nil {
+				return Result{}, err
+			}
+			return Result{Message: fmt.Sprintf("%s deleted!", displayName(e))}, nil
+		},
+	})
+	Register(Action{
+		ID:    "add_entry",
+		Label: "Add Entry",
+		Kind:  KindChange,
+		Execute: func(ctx context.Context, ls *lastpass.Service, e lastpass.Entry) (Result, error) {
+			// The workflow passes the name with its folder.
+			id, err := ls.AddEntry(ctx, lastpass.NewEntry{
+				Name:     e.Name,
+				Username: e.Username,
+				Password: e.Password,
+				URL:      e.URL,
+			})
+			if err != nil {
+				return Result{}, err
+			}
+			return Result{Value: id, Message: fmt.Sprintf("\"%s\" added to LastPass", e.Name)}, nil
+		},
+	})
+}
+
+// copyField returns an Execute func that copies field, naming it label in the message.
+func copyField(field string, label string) func(context.Context, *lastpass.Service, lastpass.Entry) (Result, error) {
+	return func(_ context.Context, ls *lastpass.Service, e lastpass.Entry) (Result, error) {
+		value, err := ls.GetField(e.ID, field)
+		if err != nil {
+			return Result{}, err
+		}
+		return Result{Value: value, Message: label + " copied!"}, nil
+	}
+}
+
+func copyNotes(ctx context.Context, ls *lastpass.Service, e lastpass.Entry) (Result, error) {
+	res, err := copyField("notes", "Notes")(ctx, ls, e)
+	if err == nil && res.Value == "" {
+		return Result{}, errNoNotes
+	}
+	return res, err
+}
+
+// displayName returns the name of the entry, falling back to its ID.
+func displayName(e lastpass.Entry) string {
+	if e.Name != "" {
+		return e.Name
+	}
+	return e.ID
+}
+
+// hasURL reports whether the entry has a real URL. lpass uses placeholder URLs
+// for secure notes and empty entries.
+func hasURL(e lastpass.Entry) bool {
+	switch e.URL {
+	case "", "http://", "http://sn", "http://group":
+		return false
+	}
+	return true
+}
+
+func copyTOTP(_ context.Context, ls *lastpass.Service, e lastpass.Entry) (Result, error) {
+	keys, details, err := ls.GetDetails(e.ID)
+	if err != nil {
+		return Result{}, err
+	}
+
+	for _, want := range TOTPFields {
+		for _, key := range keys {
+			if strings.EqualFold(key, want) && details[key] != "" {
+				code, err := TOTP(details[key], time.Now())
+				if err != nil {
+					return Result{}, err
+				}
+				return Result{Value: code, Message: "TOTP code copied!"}, nil
+			}
+		}
+	}
+	return Result{}, errors.New("entry has no TOTP secret")
+}
@@ -0,0 +1,242 @@
+// Package askpass answers the prompts lpass shows through LPASS_ASKPASS.
+package askpass
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/rwilgaard/alfred-lastpass-search/src/pkg/lastpass"
+)
+
+// changePrefix starts the prompts the workflow shows for a new field value.
+const changePrefix = "Change "
+
+// ErrCanceled is returned by prompters when the user cancels the prompt.
+var ErrCanceled = errors.New("prompt canceled")
+
+// Prompt is a question for the user.
+type Prompt struct {
+	Kind lastpass.PromptKind
+	// Text is the question shown to the user.
+	Text string
+	// Hidden prompts don't echo the answer.
+	Hidden bool
+}
+
+// Prompter asks the user a Prompt and returns the answer.
+type Prompter interface {
+	Ask(p Prompt) (string, error)
+}
+
+// Context is what NewPrompt knows besides the prompt of lpass.
+type Context struct {
+	Username string
+	// ItemName and Sensitive describe the entry of a "Change <field>" prompt.
+	ItemName  string
+	Sensitive bool
+	// Previous are the prompts already answered during this login.
+	Previous []lastpass.PromptKind
+}
+
+// NewPrompt turns the argument lpass passes to askpass into a Prompt.
+func NewPrompt(arg string, c Context) Prompt {
+	if field, ok := strings.CutPrefix(arg, changePrefix); ok {
+		return Prompt{
+			Kind:   lastpass.PromptOther,
+			Text:   fmt.Sprintf("Enter new %s for %s:", field, c.ItemName),
+			Hidden: c.Sensitive,
+		}
+	}
+
+	kind := lastpass.ParsePrompt(arg)
+	switch kind {
+	case lastpass.PromptPassword:
+		text := fmt.Sprintf("Enter %s for %s:", arg, c.Username)
+		for _, p := range c.Previous {
+			if p == lastpass.PromptPassword {
+				text = "Wrong password. " + text
+				break
+			}
+		}
+		return Prompt{Kind: kind, Text: text, Hidden: true}
+	case lastpass.PromptOTP, lastpass.PromptOutOfBand:
+		// lpass asks for most codes as just "Code".
+		if strings.EqualFold(arg, "code") {
+			arg = "OTP token"
+		}
+		return Prompt{Kind: kind, Text: fmt.Sprintf("Enter %s for %s:", arg, c.Username)}
+	}
+	return Prompt{Kind: kind, Text: fmt.Sprintf("Enter %s for %s:", arg, c.Username), Hidden: true}
+}
+
+// Run asks arg with prompter and writes the answer to w. The prompt is logged
+// to the lastpass.PromptLogEnv file when set, so Login can tell which prompts
+// lpass showed.
+func Run(arg string, c Context, prompter Prompter, w io.Writer) error {
+	p := NewPrompt(arg, c)
+	if err := logPrompt(arg); err != nil {
+		return err
+	}
+
+	answer, err := prompter.Ask(p)
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintln(w, answer)
+	return err
+}
+
+// PreviousPrompts reads the prompts logged during this login.
+func PreviousPrompts() []lastpass.PromptKind {
+	path := os.Getenv(lastpass.PromptLogEnv)
+	if path == "" {
+		return nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+
+	var kinds []lastpass.PromptKind
+	for _, l := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+		if l != "" {
+			kinds = append(kinds, lastpass.ParsePrompt(l))
+		}
+	}
+	return kinds
+}
+
+func logPrompt(arg string) error {
+	path := os.Getenv(lastpass.PromptLogEnv)
+	if path == "" {
+		return nil
+	}
+
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("error opening prompt log: %w", err)
+	}
+	defer f.Close()
+
+	_, err = fmt.Fprintln(f, arg)
+	return err
+}
+
+// PipePrompter writes the prompt text to Out and reads the answer from a line
+// of In.
+type PipePrompter struct {
+	In  io.Reader
+	Out io.Writer
+}
+
+func (pp *PipePrompter) Ask(p Prompt) (string, error) {
+	if pp.Out != nil {
+		fmt.Fprintln(pp.Out, p.Text)
+	}
+
+	line, err := bufio.NewReader(pp.In).ReadString('\n')
+	if err != nil && (err != io.EOF || line == "") {
+		return "", ErrCanceled
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+// TTYPrompter asks on the terminal at Path. Hidden answers are read with echo
+// turned off by stty.
+type TTYPrompter struct {
+	Path string
+}
+
+func (tp *TTYPrompter) Ask(p Prompt) (string, error) {
+	tty, err := os.OpenFile(tp.Path, os.O_RDWR, 0)
+	if err != nil {
+		return "", fmt.Errorf("error opening %s: %w", tp.Path, err)
+	}
+	defer tty.Close()
+
+	if p.Hidden {
+		if err := stty(tty, "-echo"); err != nil {
+			return "", err
+		}
+		defer func() {
+			_ = stty(tty, "echo")
+			fmt.Fprintln(tty)
+		}()
+	}
+
+	fmt.Fprint(tty, p.Text+" ")
+	return (&PipePrompter{In: tty}).Ask(p)
+}
+
+func stty(tty *os.File, mode string) error {
+	cmd := exec.Command("stty", mode)
+	cmd.Stdin = tty
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("error running stty %s: %w", mode, err)
+	}
+	return nil
+}
+
+// dialogScript shows a macOS dialog. Its arguments are the text, whether the
+// answer is hidden and the icon path.
+const dialogScript = `ObjC.import('stdlib')
+
+function run(argv) {
+    var app = Application.currentApplication()
+    app.includeStandardAdditions = true
+    var response = app.displayDialog(argv[0], {
+        defaultAnswer: "",
+        withIcon: Path(argv[2]),
+        buttons: ["Cancel", "OK"],
+        defaultButton: "OK",
+        cancelButton: "Cancel",
+        givingUpAfter: 120,
+        hiddenAnswer: argv[1] == "true"
+    })
+    if (response.gaveUp) {
+        $.exit(1)
+    }
+    return response.textReturned
+}`
+
+// DialogPrompter asks in a macOS dialog shown by osascript.
+type DialogPrompter struct {
+	BinPath     string
+	Icon        string
+	ExecCommand func(name string, arg ...string) *exec.Cmd
+}
+
+// NewDialogPrompter creates a DialogPrompter that shows icon.
+func NewDialogPrompter(icon string) *DialogPrompter {
+	return &DialogPrompter{
+		BinPath:     "osascript",
+		Icon:        icon,
+		ExecCommand: exec.Command,
+	}
+}
+
+func (dp *DialogPrompter) Ask(p Prompt) (string, error) {
+	hidden := "false"
+	if p.Hidden {
+		hidden = "true"
+	}
+
+	cmd := dp.ExecCommand(dp.BinPath, "-l", "JavaScript", "-e", dialogScript, p.Text, hidden, dp.Icon)
+	out, err := cmd.Output()
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			return "", ErrCanceled
+		}
+		return "", fmt.Errorf("error running osascript: %w", err)
+	}
+
+	return strings.TrimSuffix(string(out), "\n"), nil
+}
@@ -0,0 +1,130 @@
+package askpass
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/rwilgaard/alfred-lastpass-search/src/pkg/lastpass"
+)
+
+func TestNewPrompt(t *testing.T) {
+	testCases := []struct {
+		name string
+		arg  string
+		ctx  Context
+		want Prompt
+	}{
+		{
+			name: "Master password",
+			arg:  "Master Password",
+			ctx:  Context{Username: "user@example.com"},
+			want: Prompt{Kind: lastpass.PromptPassword, Text: "Enter Master Password for user@example.com:", Hidden: true},
+		},
+		{
+			name: "Master password again",
+			arg:  "Master Password",
+			ctx:  Context{Username: "user@example.com", Previous: []lastpass.PromptKind{lastpass.PromptPassword}},
+			want: Prompt{Kind: lastpass.PromptPassword, Text: "Wrong password. Enter Master Password for user@example.com:", Hidden: true},
+		},
+		{
+			name: "Code",
+			arg:  "Code",
+			ctx:  Context{Username: "user@example.com"},
+			want: Prompt{Kind: lastpass.PromptOTP, Text: "Enter OTP token for user@example.com:"},
+		},
+		{
+			name: "YubiKey",
+			arg:  "YubiKey OTP",
+			ctx:  Context{Username: "user@example.com"},
+			want: Prompt{Kind: lastpass.PromptOTP, Text: "Enter YubiKey OTP for user@example.com:"},
+		},
+		{
+			name: "Other secret",
+			arg:  "Passphrase",
+			ctx:  Context{Username: "user@example.com"},
+			want: Prompt{Kind: lastpass.PromptOther, Text: "Enter Passphrase for user@example.com:", Hidden: true},
+		},
+		{
+			name: "Change sensitive field",
+			arg:  "Change password",
+			ctx:  Context{ItemName: "GitHub", Sensitive: true},
+			want: Prompt{Kind: lastpass.PromptOther, Text: "Enter new password for GitHub:", Hidden: true},
+		},
+		{
+			name: "Change field",
+			arg:  "Change username",
+			ctx:  Context{ItemName: "GitHub"},
+			want: Prompt{Kind: lastpass.PromptOther, Text: "Enter new username for GitHub:"},
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := NewPrompt(tt.arg, tt.ctx); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("NewPrompt() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRun(t *testing.T) {
+	t.Setenv(lastpass.PromptLogEnv, filepath.Join(t.TempDir(), "prompts"))
+
+	var asked, answer bytes.Buffer
+	prompter := &PipePrompter{In: strings.NewReader("secret\n"), Out: &asked}
+	if err := Run("Master Password", Context{Username: "user@example.com"}, prompter, &answer); err != nil {
+		t.Fatalf("Run() unexpected error: %v", err)
+	}
+	if got, want := answer.String(), "secret\n"; got != want {
+		t.Errorf("Run() wrote %q, want %q", got, want)
+	}
+	if got, want := asked.String(), "Enter Master Password for user@example.com:\n"; got != want {
+		t.Errorf("Run() asked %q, want %q", got, want)
+	}
+
+	// lpass asks again after a wrong password.
+	asked.Reset()
+	prompter = &PipePrompter{In: strings.NewReader(""), Out: &asked}
+	c := Context{Username: "user@example.com", Previous: PreviousPrompts()}
+	if err := Run("Master Password", c, prompter, &answer); !errors.Is(err, ErrCanceled) {
+		t.Errorf("Run() error = %v, want %v", err, ErrCanceled)
+	}
+	if !strings.HasPrefix(asked.String(), "Wrong password.") {
+		t.Errorf("Run() asked %q, want a wrong password prompt", asked.String())
+	}
+
+	want := []lastpass.PromptKind{lastpass.PromptPassword, lastpass.PromptPassword}
+	if got := PreviousPrompts(); !reflect.DeepEqual(got, want) {
+		t.Errorf("PreviousPrompts() = %v, want %v", got, want)
+	}
+}
+
+func TestDialogPrompter(t *testing.T) {
+	// The fake osascript answers with its arguments: the text, whether the
+	// answer is hidden and the icon.
+	path := filepath.Join(t.TempDir(), "osascript")
+	script := "#!/bin/sh\n[ \"$5\" = cancel ] && exit 1\necho \"$5|$6|$7\"\n"
+	if err := os.WriteFile(path, []byte(script), 0o755); err != nil {
+		t.Fatalf("writing osascript: %v", err)
+	}
+
+	dp := &DialogPrompter{BinPath: path, Icon: "icon.png", ExecCommand: exec.Command}
+
+	got, err := dp.Ask(Prompt{Text: "Enter Code", Hidden: true})
+	if err != nil {
+		t.Fatalf("Ask() unexpected error: %v", err)
+	}
+	if want := "Enter Code|true|icon.png"; got != want {
+		t.Errorf("Ask() = %q, want %q", got, want)
+	}
+
+	if _, err := dp.Ask(Prompt{Text: "cancel"}); !errors.Is(err, ErrCanceled) {
+		t.Errorf("Ask() error = %v, want %v", err, ErrCanceled)
+	}
+}
@@ -0,0 +1,77 @@
+package lastpass
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// defaultBinary is the name lpass is looked up by when no path is configured.
+const defaultBinary = "lpass"
+
+// binDirs are where Homebrew and Nix install lpass. Alfred doesn't run the
+// workflow with the PATH of a login shell, so these are searched as well.
+var binDirs = []string{
+	"/opt/homebrew/bin",
+	"/usr/local/bin",
+	"~/.nix-profile/bin",
+	"/etc/profiles/per-user/$USER/bin",
+	"/run/current-system/sw/bin",
+	"/nix/var/nix/profiles/default/bin",
+}
+
+// FindBinary resolves the lpass binary. A path with a slash must point to an
+// executable file. A bare name, or an empty path for "lpass", is looked up in
+// PATH and then in the usual install locations.
+func FindBinary(path string) (string, error) {
+	if path == "" {
+		path = defaultBinary
+	}
+
+	if strings.Contains(path, "/") {
+		path = ExpandPath(path)
+		if err := checkExecutable(path); err != nil {
+			return "", err
+		}
+		return path, nil
+	}
+
+	if found, err := exec.LookPath(path); err == nil {
+		return found, nil
+	}
+	for _, dir := range binDirs {
+		candidate := filepath.Join(ExpandPath(dir), path)
+		if checkExecutable(candidate) == nil {
+			return candidate, nil
+		}
+	}
+
+	return "", fmt.Errorf("%s not found in PATH or %s", path, strings.Join(binDirs, ", "))
+}
+
+func checkExecutable(path string) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("%s does not exist", path)
+		}
+		return err
+	}
+	if info.IsDir() || info.Mode().Perm()&0o111 == 0 {
+		return fmt.Errorf("%s is not executable", path)
+	}
+	return nil
+}
+
+// ExpandPath expands a leading ~ and environment variables in path.
+func ExpandPath(path string) string {
+	if rest, ok := strings.CutPrefix(path, "~/"); ok {
+		if home, err := os.UserHomeDir(); err == nil {
+			path = filepath.Join(home, rest)
+		}
+	}
+	return os.ExpandEnv(path)
+}
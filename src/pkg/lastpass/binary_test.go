@@ -0,0 +1,49 @@
+package lastpass
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFindBinary(t *testing.T) {
+	dir := t.TempDir()
+	lpass := filepath.Join(dir, "lpass")
+	if err := os.WriteFile(lpass, []byte("#!/bin/sh\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	notExecutable := filepath.Join(dir, "lpass.txt")
+	if err := os.WriteFile(notExecutable, []byte(""), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", dir)
+	t.Setenv("HOME", dir)
+
+	testCases := []struct {
+		name    string
+		path    string
+		want    string
+		wantErr bool
+	}{
+		{name: "Default", path: "", want: lpass},
+		{name: "Name in PATH", path: "lpass", want: lpass},
+		{name: "Absolute path", path: lpass, want: lpass},
+		{name: "Home path", path: "~/lpass", want: lpass},
+		{name: "Missing name", path: "lpass-missing", wantErr: true},
+		{name: "Missing path", path: filepath.Join(dir, "missing"), wantErr: true},
+		{name: "Not executable", path: notExecutable, wantErr: true},
+		{name: "Directory", path: dir + "/", wantErr: true},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := FindBinary(tt.path)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("FindBinary() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("FindBinary() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
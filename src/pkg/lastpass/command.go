@@ -0,0 +1,47 @@
+package lastpass
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"os/exec"
+)
+
+// output runs cmd like cmd.Output, but kills it when ctx is done. Failed
+// commands are matched to the Service errors with lpassError.
+func output(ctx context.Context, cmd *exec.Cmd, stdin io.Reader) ([]byte, error) {
+	stdout, _, err := run(ctx, cmd, stdin)
+	return stdout, err
+}
+
+// run is output for commands whose stderr matters even when they succeed.
+func run(ctx context.Context, cmd *exec.Cmd, stdin io.Reader) ([]byte, []byte, error) {
+	var stdout, stderr bytes.Buffer
+	cmd.Stdin = stdin
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Start(); err != nil {
+		return nil, nil, err
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- cmd.Wait()
+	}()
+
+	select {
+	case err := <-done:
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			exitErr.Stderr = stderr.Bytes()
+			return stdout.Bytes(), stderr.Bytes(), lpassError(exitErr)
+		}
+		return stdout.Bytes(), stderr.Bytes(), err
+	case <-ctx.Done():
+		_ = cmd.Process.Kill()
+		<-done
+		return nil, nil, ctx.Err()
+	}
+}
@@ -0,0 +1,86 @@
+package lastpass
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// WriteOptions are the checks UpdateField, MoveEntry and DeleteEntry run
+// before changing an entry.
+type WriteOptions struct {
+	// IfUnmodifiedSince is the modification time of the caller's copy of the
+	// entry. The write is refused with ErrConflict if the entry was modified
+	// after it, e.g. by someone else, and with ErrNoLastModified if it is zero.
+	IfUnmodifiedSince time.Time
+	// Precision is how precisely IfUnmodifiedSince is known. Zero means to the
+	// second.
+	Precision time.Duration
+}
+
+// LastModified reads the last modification time of an entry. With sync the
+// vault is synced first, so changes made elsewhere are seen.
+func (ls *Service) LastModified(ctx context.Context, itemID string, sync bool) (time.Time, error) {
+	if len(itemID) == 0 {
+		return time.Time{}, errors.New("itemID is empty")
+	}
+
+	syncArg := "--sync=no"
+	if sync {
+		syncArg = "--sync=now"
+	}
+
+	cmd := ls.command("show", syncArg, "--json", itemID)
+	out, err := output(ctx, cmd, nil)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("error running lpass show for itemID '%s': %w", itemID, err)
+	}
+
+	var entries []struct {
+		ID              string `json:"id"`
+		LastModifiedGMT string `json:"last_modified_gmt"`
+	}
+	if err := json.Unmarshal(out, &entries); err != nil {
+		return time.Time{}, fmt.Errorf("error parsing lpass show output for itemID '%s': %w", itemID, err)
+	}
+
+	for _, e := range entries {
+		if e.ID != itemID {
+			continue
+		}
+		secs, err := strconv.ParseInt(e.LastModifiedGMT, 10, 64)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("invalid last_modified_gmt '%s' for itemID '%s'", e.LastModifiedGMT, itemID)
+		}
+		return time.Unix(secs, 0).UTC(), nil
+	}
+
+	return time.Time{}, fmt.Errorf("%w: '%s'", ErrNotFound, itemID)
+}
+
+// checkUnchanged returns ErrConflict if the entry was modified after
+// opts.IfUnmodifiedSince. Without a time there is nothing to compare against,
+// so the write is refused with ErrNoLastModified. The vault is synced first,
+// so every checked write costs an extra lpass show.
+func (ls *Service) checkUnchanged(ctx context.Context, itemID string, opts WriteOptions) error {
+	if opts.IfUnmodifiedSince.IsZero() {
+		return fmt.Errorf("%w: '%s'", ErrNoLastModified, itemID)
+	}
+	precision := opts.Precision
+	if precision == 0 {
+		precision = time.Second
+	}
+
+	got, err := ls.LastModified(ctx, itemID, true)
+	if err != nil {
+		return err
+	}
+	if !got.Truncate(precision).Equal(opts.IfUnmodifiedSince.Truncate(precision)) {
+		return fmt.Errorf("%w: '%s' was modified %s", ErrConflict, itemID, got.Local().Format(lpassTimeLayout))
+	}
+
+	return nil
+}
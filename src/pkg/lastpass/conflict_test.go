@@ -0,0 +1,126 @@
+package lastpass
+
+import (
+	"context"
+	"errors"
+	"os/exec"
+	"testing"
+	"time"
+)
+
+func TestLastpassServiceLastModified(t *testing.T) {
+	testCases := []struct {
+		name       string
+		mockStdout string
+		want       time.Time
+		wantErr    error
+	}{
+		{
+			name:       "Found",
+			mockStdout: `[{"id": "123", "name": "Example", "last_modified_gmt": "1700000000", "last_touch": "1700000100"}]`,
+			want:       time.Unix(1700000000, 0).UTC(),
+		},
+		{
+			name:       "Other entry with the same name",
+			mockStdout: `[{"id": "456", "last_modified_gmt": "1700000000"}]`,
+			wantErr:    ErrNotFound,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			ls := &Service{
+				BinPath:     "lpass",
+				ExecCommand: mockExecCommand(t, tt.mockStdout, "", 0),
+			}
+
+			got, err := ls.LastModified(context.Background(), "123", true)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("LastModified() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("LastModified() unexpected error: %v", err)
+			}
+			if !got.Equal(tt.want) {
+				t.Errorf("LastModified() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWritesRefuseConflicts(t *testing.T) {
+	remote := `[{"id": "123", "last_modified_gmt": "1700000000"}]`
+
+	writes := map[string]func(ls *Service, opts WriteOptions) error{
+		"UpdateField": func(ls *Service, opts WriteOptions) error {
+			return ls.UpdateField(context.Background(), "123", "password", "new", opts)
+		},
+		"DeleteEntry": func(ls *Service, opts WriteOptions) error {
+			return ls.DeleteEntry(context.Background(), "123", opts)
+		},
+		"MoveEntry": func(ls *Service, opts WriteOptions) error {
+			return ls.MoveEntry(context.Background(), "123", "Shared-Team/", opts)
+		},
+	}
+
+	testCases := []struct {
+		name      string
+		opts      WriteOptions
+		wantErr   error
+		wantWrite bool
+	}{
+		{
+			name:      "Unchanged",
+			opts:      WriteOptions{IfUnmodifiedSince: time.Unix(1700000000, 0)},
+			wantWrite: true,
+		},
+		{
+			name:    "Changed remotely",
+			opts:    WriteOptions{IfUnmodifiedSince: time.Unix(1690000000, 0)},
+			wantErr: ErrConflict,
+		},
+		{
+			name:      "Unchanged listed minute",
+			opts:      WriteOptions{IfUnmodifiedSince: time.Unix(1700000000, 0).Truncate(time.Minute), Precision: time.Minute},
+			wantWrite: true,
+		},
+		{
+			name:    "Changed since the listed minute",
+			opts:    WriteOptions{IfUnmodifiedSince: time.Unix(1700000000-60, 0), Precision: time.Minute},
+			wantErr: ErrConflict,
+		},
+		{
+			name:    "No copy to compare against",
+			wantErr: ErrNoLastModified,
+		},
+	}
+
+	for write, run := range writes {
+		for _, tt := range testCases {
+			t.Run(write+"/"+tt.name, func(t *testing.T) {
+				var wrote bool
+				ls := &Service{
+					BinPath: "lpass",
+					ExecCommand: func(name string, args ...string) *exec.Cmd {
+						if args[0] == "show" {
+							return mockExecCommand(t, remote, "", 0)(name, args...)
+						}
+						wrote = true
+						return mockExecCommand(t, "", "", 0)(name, args...)
+					},
+				}
+
+				err := run(ls, tt.opts)
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("%s() error = %v, want %v", write, err, tt.wantErr)
+				}
+				if wrote != tt.wantWrite {
+					t.Errorf("%s() wrote = %v, want %v", write, wrote, tt.wantWrite)
+				}
+			})
+		}
+	}
+}
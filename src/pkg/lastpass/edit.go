@@ -0,0 +1,352 @@
+package lastpass
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/rwilgaard/alfred-lastpass-search/src/pkg/util"
+)
+
+// NewEntry holds the fields of an entry to add.
+type NewEntry struct {
+	Name     string
+	Folder   string
+	Username string
+	Password string
+	URL      string
+	Notes    string
+	// Fields are custom fields, added in name order.
+	Fields map[string]string
+}
+
+// FullName returns the name of the entry including its folder, as lpass expects it.
+func (e NewEntry) FullName() string {
+	folder := strings.Trim(e.Folder, "/")
+	if folder == "" {
+		return e.Name
+	}
+	return folder + "/" + e.Name
+}
+
+var entryIDRegex = regexp.MustCompile(`\[id: ([0-9]+)\]`)
+
+// AddEntry adds an entry, syncs it right away and returns its ID.
+func (ls *Service) AddEntry(ctx context.Context, e NewEntry) (string, error) {
+	if len(e.Name) == 0 {
+		return "", errors.New("name is empty")
+	}
+
+	payload, err := newEntryPayload(e)
+	if err != nil {
+		return "", err
+	}
+
+	// lpass add doesn't always print the new ID, so the entries are listed
+	// before to tell which one is new.
+	before, err := ls.entryNames(ctx, e.Folder, false)
+	if err != nil {
+		return "", err
+	}
+
+	name := e.FullName()
+	cmd := ls.command("add", "--non-interactive", "--sync=now", name)
+	out, err := output(ctx, cmd, strings.NewReader(payload))
+	if err != nil {
+		return "", fmt.Errorf("error running lpass add for '%s': %w", name, err)
+	}
+
+	if id := util.RegexSearch(entryIDRegex, string(out)); id != "" && id != unsyncedID {
+		return id, nil
+	}
+
+	after, err := ls.entryNames(ctx, e.Folder, true)
+	if err != nil {
+		return "", err
+	}
+	return newEntryID(before, after, name)
+}
+
+// DuplicateOptions are the changes DuplicateEntry makes to the copy. Empty
+// values are copied from the original.
+type DuplicateOptions struct {
+	Name     string
+	Folder   string
+	Password string
+}
+
+// DuplicateEntry copies an entry, applies opts to the copy and returns its ID.
+// If changing the copy fails, the copy is removed again. Only if that fails
+// too is its ID returned with the error.
+func (ls *Service) DuplicateEntry(ctx context.Context, itemID string, opts DuplicateOptions) (string, error) {
+	if len(itemID) == 0 {
+		return "", errors.New("itemID is empty")
+	}
+
+	// lpass duplicate doesn't print the new ID, so the entries are listed
+	// before to tell which one is new. The copy has the same name as the
+	// original.
+	before, err := ls.entryNames(ctx, "", false)
+	if err != nil {
+		return "", err
+	}
+	name, ok := before[itemID]
+	if !ok {
+		return "", fmt.Errorf("%w: '%s'", ErrNotFound, itemID)
+	}
+
+	cmd := ls.command("duplicate", "--sync=now", itemID)
+	if _, err := output(ctx, cmd, nil); err != nil {
+		return "", fmt.Errorf("error running lpass duplicate for itemID '%s': %w", itemID, err)
+	}
+
+	after, err := ls.entryNames(ctx, "", true)
+	if err != nil {
+		return "", err
+	}
+	newID, err := newEntryID(before, after, name)
+	if err != nil {
+		return "", err
+	}
+
+	// The copy is new, so changes to it are not checked for conflicts.
+	if err := ls.changeCopy(ctx, newID, path.Dir(name), opts); err != nil {
+		// Don't leave a half-changed copy under the original's name.
+		if rmErr := ls.deleteEntry(context.WithoutCancel(ctx), newID); rmErr != nil {
+			return newID, errors.Join(err, fmt.Errorf("removing copy '%s': %w", newID, rmErr))
+		}
+		return "", err
+	}
+
+	return newID, nil
+}
+
+// changeCopy applies opts to the copy newID of an entry in folder. lpass edit
+// --name sets the full name, so the copy is renamed within its folder and then
+// moved.
+func (ls *Service) changeCopy(ctx context.Context, newID string, folder string, opts DuplicateOptions) error {
+	if folder == "." {
+		folder = ""
+	}
+	if opts.Name != "" {
+		if err := ls.updateField(ctx, newID, "name", path.Join(folder, opts.Name)); err != nil {
+			return err
+		}
+	}
+	if opts.Password != "" {
+		if err := ls.updateField(ctx, newID, "password", opts.Password); err != nil {
+			return err
+		}
+	}
+	if target := strings.TrimSuffix(opts.Folder, "/"); target != "" && target != folder {
+		if err := ls.moveEntry(ctx, newID, target); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// unsyncedID is the ID lpass gives an entry until the server assigns one.
+const unsyncedID = "0"
+
+// entryNames returns the full name of every entry in folder by ID. With sync
+// the vault is synced first, so new entries have the ID the server assigned.
+func (ls *Service) entryNames(ctx context.Context, folder string, sync bool) (map[string]string, error) {
+	syncArg := "--sync=no"
+	if sync {
+		syncArg = "--sync=now"
+	}
+
+	cmd := ls.command("ls", syncArg, "--format", "[id: %ai] %/as%/ag%an", folder)
+	out, err := output(ctx, cmd, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error running lpass ls for folder '%s': %w", folder, err)
+	}
+
+	names := make(map[string]string)
+	for _, l := range strings.Split(string(out), "\n") {
+		id := util.RegexSearch(entryIDRegex, l)
+		if id == "" {
+			continue
+		}
+		names[id] = strings.TrimSpace(entryIDRegex.ReplaceAllString(l, ""))
+	}
+
+	return names, nil
+}
+
+// newEntryID returns the ID of the entry called name that is in after but
+// not in before. Entries with the same name are common, so anything but a
+// single new entry is an error rather than a guess.
+func newEntryID(before map[string]string, after map[string]string, name string) (string, error) {
+	var ids []string
+	for id, n := range after {
+		if _, ok := before[id]; !ok && n == name {
+			ids = append(ids, id)
+		}
+	}
+
+	switch {
+	case len(ids) == 0:
+		return "", fmt.Errorf("%w: '%s' was added but could not be found", ErrNotFound, name)
+	case len(ids) > 1:
+		sort.Strings(ids)
+		return "", fmt.Errorf("'%s' was added, but %d new entries have that name: %s", name, len(ids), strings.Join(ids, ", "))
+	case ids[0] == unsyncedID:
+		return "", fmt.Errorf("'%s' was added but has no ID yet, sync and try again", name)
+	}
+	return ids[0], nil
+}
+
+// newEntryPayload builds the text lpass add --non-interactive reads from stdin.
+// Every field is a "Name: value" line and can't contain a line break, except
+// the notes which take up the rest of the input and may span several lines. A
+// custom field called Notes is added to the notes.
+func newEntryPayload(e NewEntry) (string, error) {
+	for _, f := range [][2]string{{"Name", e.Name}, {"Folder", e.Folder}} {
+		if strings.ContainsAny(f[1], "\r\n") {
+			return "", fmt.Errorf("field '%s' can't contain a line break", f[0])
+		}
+	}
+
+	fields := [][2]string{
+		{"Username", e.Username},
+		{"Password", e.Password},
+		{"URL", e.URL},
+	}
+	notes := []string{e.Notes}
+
+	names := make([]string, 0, len(e.Fields))
+	for name := range e.Fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if name == "" || strings.ContainsAny(name, ":\r\n") {
+			return "", fmt.Errorf("invalid field name '%s'", name)
+		}
+		if strings.EqualFold(name, "notes") {
+			notes = append(notes, e.Fields[name])
+			continue
+		}
+		fields = append(fields, [2]string{name, e.Fields[name]})
+	}
+
+	var b strings.Builder
+	for _, f := range fields {
+		if strings.ContainsAny(f[1], "\r\n") {
+			return "", fmt.Errorf("field '%s' can't contain a line break", f[0])
+		}
+		if f[1] == "" {
+			continue
+		}
+		fmt.Fprintf(&b, "%s: %s\n", f[0], f[1])
+	}
+	notes = slices.DeleteFunc(notes, func(n string) bool { return n == "" })
+	if len(notes) > 0 {
+		fmt.Fprintf(&b, "Notes:\n%s\n", strings.Join(notes, "\n"))
+	}
+
+	return b.String(), nil
+}
+
+// UpdateField sets a single field of an entry and syncs the change right away.
+// The standard fields name, username, password, url and notes have their own
+// flag, anything else is set as a custom field. Only notes may span several
+// lines. The entry is checked against opts first.
+func (ls *Service) UpdateField(ctx context.Context, itemID string, field string, value string, opts WriteOptions) error {
+	if len(itemID) == 0 {
+		return errors.New("itemID is empty")
+	}
+	if err := ls.checkUnchanged(ctx, itemID, opts); err != nil {
+		return err
+	}
+	return ls.updateField(ctx, itemID, field, value)
+}
+
+// updateField is UpdateField without the check, for entries the caller just
+// made.
+func (ls *Service) updateField(ctx context.Context, itemID string, field string, value string) error {
+	if len(itemID) == 0 {
+		return errors.New("itemID is empty")
+	}
+	if len(field) == 0 {
+		return errors.New("field is empty")
+	}
+
+	arg := fieldArg(field, "name", "username", "password", "url", "notes")
+	if arg != "--notes" && strings.ContainsAny(value, "\r\n") {
+		return fmt.Errorf("field '%s' can't contain a line break", field)
+	}
+
+	cmd := ls.command("edit", "--non-interactive", "--sync=now", arg, itemID)
+	if _, err := output(ctx, cmd, strings.NewReader(value)); err != nil {
+		return fmt.Errorf("error running lpass edit for field '%s' of itemID '%s': %w", field, itemID, err)
+	}
+
+	return nil
+}
+
+// MoveEntry moves an entry to folder and syncs the change right away. Moving
+// into or out of a shared folder changes who can see the entry. The entry is
+// checked against opts first.
+func (ls *Service) MoveEntry(ctx context.Context, itemID string, folder string, opts WriteOptions) error {
+	if len(itemID) == 0 {
+		return errors.New("itemID is empty")
+	}
+	if err := ls.checkUnchanged(ctx, itemID, opts); err != nil {
+		return err
+	}
+	return ls.moveEntry(ctx, itemID, folder)
+}
+
+// moveEntry is MoveEntry without the check, for moves that keep the entry
+// itself as it is, like renaming its folder.
+func (ls *Service) moveEntry(ctx context.Context, itemID string, folder string) error {
+	if len(itemID) == 0 {
+		return errors.New("itemID is empty")
+	}
+	folder = strings.TrimSuffix(folder, "/")
+	if len(folder) == 0 {
+		return errors.New("folder is empty")
+	}
+
+	cmd := ls.command("mv", "--sync=now", itemID, folder)
+	if _, err := output(ctx, cmd, nil); err != nil {
+		return fmt.Errorf("error running lpass mv for itemID '%s' to '%s': %w", itemID, folder, err)
+	}
+
+	return nil
+}
+
+// DeleteEntry removes an entry and syncs the change right away. The entry is
+// checked against opts first.
+func (ls *Service) DeleteEntry(ctx context.Context, itemID string, opts WriteOptions) error {
+	if len(itemID) == 0 {
+		return errors.New("itemID is empty")
+	}
+	if err := ls.checkUnchanged(ctx, itemID, opts); err != nil {
+		return err
+	}
+	return ls.deleteEntry(ctx, itemID)
+}
+
+// deleteEntry is DeleteEntry without the check, for entries that hold no data
+// of their own, like folder placeholders.
+func (ls *Service) deleteEntry(ctx context.Context, itemID string) error {
+	if len(itemID) == 0 {
+		return errors.New("itemID is empty")
+	}
+
+	cmd := ls.command("rm", "--sync=now", itemID)
+	if _, err := output(ctx, cmd, nil); err != nil {
+		return fmt.Errorf("error running lpass rm for itemID '%s': %w", itemID, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,487 @@
+package lastpass
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os/exec"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestLastpassServiceAddEntry(t *testing.T) {
+	testCases := []struct {
+		name            string
+		entry           NewEntry
+		addStdout       string
+		addStderr       string
+		addExitCode     int
+		lsBefore        string
+		lsAfter         string
+		wantID          string
+		wantErr         bool
+		expectedErrText string
+	}{
+		{
+			name:      "ID from lpass output",
+			entry:     NewEntry{Name: "Example", Folder: "Work", Username: "user"},
+			addStdout: "Added [id: 555]\n",
+			wantID:    "555",
+		},
+		{
+			name:     "ID from listing picks the new entry",
+			entry:    NewEntry{Name: "Example", Folder: "Work/"},
+			lsBefore: "[id: 100] Work/Example\n[id: 200] Work/Other\n",
+			lsAfter:  "[id: 100] Work/Example\n[id: 300] Work/Example\n[id: 200] Work/Other\n[id: 400] Work/Example copy\n",
+			wantID:   "300",
+		},
+		{
+			name:            "Missing from listing",
+			entry:           NewEntry{Name: "Example"},
+			lsBefore:        "[id: 100] Other\n",
+			lsAfter:         "[id: 100] Other\n",
+			wantErr:         true,
+			expectedErrText: "'Example' was added but could not be found",
+		},
+		{
+			name:            "Several new entries with the name",
+			entry:           NewEntry{Name: "Example"},
+			lsAfter:         "[id: 300] Example\n[id: 400] Example\n",
+			wantErr:         true,
+			expectedErrText: "'Example' was added, but 2 new entries have that name: 300, 400",
+		},
+		{
+			name:            "No ID from the server yet",
+			entry:           NewEntry{Name: "Example"},
+			lsAfter:         "[id: 0] Example\n",
+			wantErr:         true,
+			expectedErrText: "'Example' was added but has no ID yet",
+		},
+		{
+			name:            "Empty name",
+			entry:           NewEntry{Username: "user"},
+			wantErr:         true,
+			expectedErrText: "name is empty",
+		},
+		{
+			name:            "Line break in username",
+			entry:           NewEntry{Name: "Example", Username: "user\nPassword: injected"},
+			wantErr:         true,
+			expectedErrText: "field 'Username' can't contain a line break",
+		},
+		{
+			name:            "Network failure",
+			entry:           NewEntry{Name: "Example"},
+			addStderr:       "Error: Could not connect to server.\n",
+			addExitCode:     1,
+			wantErr:         true,
+			expectedErrText: "could not reach LastPass: Could not connect to server.",
+		},
+		{
+			name:            "Unknown failure keeps the lpass message",
+			entry:           NewEntry{Name: "Example"},
+			addStderr:       "Error: Something odd happened.\n",
+			addExitCode:     1,
+			wantErr:         true,
+			expectedErrText: "Something odd happened.",
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			ls := &Service{
+				BinPath: "lpass",
+				ExecCommand: func(name string, args ...string) *exec.Cmd {
+					switch {
+					case args[0] == "add":
+						return mockExecCommand(t, tt.addStdout, tt.addStderr, tt.addExitCode)(name, args...)
+					case slices.Contains(args, "--sync=now"):
+						return mockExecCommand(t, tt.lsAfter, "", 0)(name, args...)
+					}
+					return mockExecCommand(t, tt.lsBefore, "", 0)(name, args...)
+				},
+			}
+
+			id, err := ls.AddEntry(context.Background(), tt.entry)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("AddEntry() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				if !strings.Contains(err.Error(), tt.expectedErrText) {
+					t.Errorf("AddEntry() error = %v, want error containing %q", err, tt.expectedErrText)
+				}
+				return
+			}
+			if id != tt.wantID {
+				t.Errorf("AddEntry() = %q, want %q", id, tt.wantID)
+			}
+		})
+	}
+}
+
+func TestNewEntryPayload(t *testing.T) {
+	testCases := []struct {
+		name    string
+		entry   NewEntry
+		want    string
+		wantErr bool
+	}{
+		{
+			name:  "Standard fields",
+			entry: NewEntry{Username: "user", Password: `p%s\n"'`, URL: "https://example.com"},
+			want:  "Username: user\nPassword: p%s\\n\"'\nURL: https://example.com\n",
+		},
+		{
+			name: "Custom fields in name order and multi-line notes last",
+			entry: NewEntry{
+				Password: "pass",
+				Notes:    "line one\nNotes: line two",
+				Fields:   map[string]string{"PIN": "1234", "Account": "42"},
+			},
+			want: "Password: pass\nAccount: 42\nPIN: 1234\nNotes:\nline one\nNotes: line two\n",
+		},
+		{
+			name:    "Field name with a colon",
+			entry:   NewEntry{Fields: map[string]string{"a:b": "c"}},
+			wantErr: true,
+		},
+		{
+			name: "Custom notes field with line breaks",
+			entry: NewEntry{
+				Username: "user",
+				Fields:   map[string]string{"notes": "first\nsecond", "PIN": "1234"},
+			},
+			want: "Username: user\nPIN: 1234\nNotes:\nfirst\nsecond\n",
+		},
+		{
+			name:    "Line break in name",
+			entry:   NewEntry{Name: "Mail\nother", Notes: "line one\nline two"},
+			wantErr: true,
+		},
+		{
+			name:    "Line break in custom field",
+			entry:   NewEntry{Fields: map[string]string{"PIN": "1\r\n2"}},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := newEntryPayload(tt.entry)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("newEntryPayload() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("newEntryPayload() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLastpassServiceDeleteEntry(t *testing.T) {
+	testCases := []struct {
+		name         string
+		itemID       string
+		mockStderr   string
+		mockExitCode int
+		wantErr      error
+	}{
+		{
+			name:   "Deleted",
+			itemID: "123",
+		},
+		{
+			name:         "Not found",
+			itemID:       "123",
+			mockStderr:   "Error: Could not find specified account '123'.\n",
+			mockExitCode: 1,
+			wantErr:      ErrNotFound,
+		},
+		{
+			name:         "Not logged in",
+			itemID:       "123",
+			mockStderr:   "Error: Could not find decryption key. Perhaps you need to login with `lpass login`.\n",
+			mockExitCode: 1,
+			wantErr:      ErrNotLoggedIn,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			ls := &Service{
+				BinPath:     "lpass",
+				ExecCommand: mockExecCommand(t, "", tt.mockStderr, tt.mockExitCode),
+			}
+
+			err := ls.deleteEntry(context.Background(), tt.itemID)
+			if tt.wantErr == nil {
+				if err != nil {
+					t.Fatalf("DeleteEntry() unexpected error: %v", err)
+				}
+				return
+			}
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("DeleteEntry() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestLastpassServiceUpdateField(t *testing.T) {
+	testCases := []struct {
+		name            string
+		itemID          string
+		field           string
+		value           string
+		mockStderr      string
+		mockExitCode    int
+		wantArgs        []string
+		wantErr         error
+		expectedErrText string
+	}{
+		{
+			name:     "Standard field",
+			itemID:   "123",
+			field:    "Password",
+			value:    "n3w%pass",
+			wantArgs: []string{"edit", "--non-interactive", "--sync=now", "--password", "123"},
+		},
+		{
+			name:     "Custom field",
+			itemID:   "123",
+			field:    "PIN",
+			value:    "1234",
+			wantArgs: []string{"edit", "--non-interactive", "--sync=now", "--field=PIN", "123"},
+		},
+		{
+			name:     "Multi-line notes",
+			itemID:   "123",
+			field:    "Notes",
+			value:    "line one\nline two",
+			wantArgs: []string{"edit", "--non-interactive", "--sync=now", "--notes", "123"},
+		},
+		{
+			name:            "Line break in username",
+			itemID:          "123",
+			field:           "Username",
+			value:           "a\nb",
+			expectedErrText: "field 'Username' can't contain a line break",
+		},
+		{
+			name:            "Empty itemID",
+			field:           "Username",
+			expectedErrText: "itemID is empty",
+		},
+		{
+			name:         "Not found",
+			itemID:       "123",
+			field:        "Username",
+			mockStderr:   "Error: Could not find specified account '123'.\n",
+			mockExitCode: 1,
+			wantErr:      ErrNotFound,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotArgs []string
+			ls := &Service{
+				BinPath: "lpass",
+				ExecCommand: func(name string, args ...string) *exec.Cmd {
+					gotArgs = args
+					return mockExecCommand(t, "", tt.mockStderr, tt.mockExitCode)(name, args...)
+				},
+			}
+
+			err := ls.updateField(context.Background(), tt.itemID, tt.field, tt.value)
+			switch {
+			case tt.wantErr != nil:
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("UpdateField() error = %v, want %v", err, tt.wantErr)
+				}
+			case tt.expectedErrText != "":
+				if err == nil || !strings.Contains(err.Error(), tt.expectedErrText) {
+					t.Errorf("UpdateField() error = %v, want error containing %q", err, tt.expectedErrText)
+				}
+			case err != nil:
+				t.Fatalf("UpdateField() unexpected error: %v", err)
+			default:
+				if strings.Join(gotArgs, " ") != strings.Join(tt.wantArgs, " ") {
+					t.Errorf("UpdateField() ran lpass %v, want %v", gotArgs, tt.wantArgs)
+				}
+			}
+		})
+	}
+}
+
+func TestLastpassServiceDuplicateEntry(t *testing.T) {
+	// 180 is an older entry with the same name as the original.
+	before := "[id: 100] Work/Example\n[id: 150] Work/Other\n[id: 180] Work/Example\n"
+	after := before + "[id: 200] Work/Example\n"
+
+	testCases := []struct {
+		name         string
+		itemID       string
+		opts         DuplicateOptions
+		after        string
+		failOn       []string
+		wantID       string
+		wantCommands []string
+		// wantName is the full name given to lpass edit --name.
+		wantName string
+		wantErr  bool
+	}{
+		{
+			name:   "Plain copy",
+			itemID: "100",
+			wantID: "200",
+			wantCommands: []string{
+				"ls --sync=no --format [id: %ai] %/as%/ag%an ",
+				"duplicate --sync=now 100",
+				"ls --sync=now --format [id: %ai] %/as%/ag%an ",
+			},
+		},
+		{
+			name:   "Renamed copy with a new password in another folder",
+			itemID: "100",
+			opts:   DuplicateOptions{Name: "Example (staging)", Folder: "Staging/", Password: "n3w"},
+			wantID: "200",
+			wantCommands: []string{
+				"ls --sync=no --format [id: %ai] %/as%/ag%an ",
+				"duplicate --sync=now 100",
+				"ls --sync=now --format [id: %ai] %/as%/ag%an ",
+				"edit --non-interactive --sync=now --name 200",
+				"edit --non-interactive --sync=now --password 200",
+				"mv --sync=now 200 Staging",
+			},
+			wantName: "Work/Example (staging)",
+		},
+		{
+			name:   "Renamed copy in the same folder",
+			itemID: "100",
+			opts:   DuplicateOptions{Name: "Example (copy)", Folder: "Work/"},
+			wantID: "200",
+			wantCommands: []string{
+				"ls --sync=no --format [id: %ai] %/as%/ag%an ",
+				"duplicate --sync=now 100",
+				"ls --sync=now --format [id: %ai] %/as%/ag%an ",
+				"edit --non-interactive --sync=now --name 200",
+			},
+			wantName: "Work/Example (copy)",
+		},
+		{
+			name:   "Same folder is not a move",
+			itemID: "100",
+			opts:   DuplicateOptions{Folder: "Work/"},
+			wantID: "200",
+			wantCommands: []string{
+				"ls --sync=no --format [id: %ai] %/as%/ag%an ",
+				"duplicate --sync=now 100",
+				"ls --sync=now --format [id: %ai] %/as%/ag%an ",
+			},
+		},
+		{
+			name:    "Failed move removes the copy",
+			itemID:  "100",
+			opts:    DuplicateOptions{Name: "Example (staging)", Folder: "Staging/"},
+			failOn:  []string{"mv"},
+			wantErr: true,
+			wantCommands: []string{
+				"ls --sync=no --format [id: %ai] %/as%/ag%an ",
+				"duplicate --sync=now 100",
+				"ls --sync=now --format [id: %ai] %/as%/ag%an ",
+				"edit --non-interactive --sync=now --name 200",
+				"mv --sync=now 200 Staging",
+				"rm --sync=now 200",
+			},
+		},
+		{
+			name:    "Copy that can't be removed is returned",
+			itemID:  "100",
+			opts:    DuplicateOptions{Password: "n3w"},
+			failOn:  []string{"edit", "rm"},
+			wantID:  "200",
+			wantErr: true,
+			wantCommands: []string{
+				"ls --sync=no --format [id: %ai] %/as%/ag%an ",
+				"duplicate --sync=now 100",
+				"ls --sync=now --format [id: %ai] %/as%/ag%an ",
+				"edit --non-interactive --sync=now --password 200",
+				"rm --sync=now 200",
+			},
+		},
+		{
+			name:    "Copy not synced yet",
+			itemID:  "100",
+			opts:    DuplicateOptions{Name: "Example (staging)"},
+			after:   before + "[id: 0] Work/Example\n",
+			wantErr: true,
+			wantCommands: []string{
+				"ls --sync=no --format [id: %ai] %/as%/ag%an ",
+				"duplicate --sync=now 100",
+				"ls --sync=now --format [id: %ai] %/as%/ag%an ",
+			},
+		},
+		{
+			name:    "Unknown original",
+			itemID:  "999",
+			wantErr: true,
+			wantCommands: []string{
+				"ls --sync=no --format [id: %ai] %/as%/ag%an ",
+			},
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			var commands []string
+			var rename *exec.Cmd
+			ls := &Service{
+				BinPath: "lpass",
+				ExecCommand: func(name string, args ...string) *exec.Cmd {
+					commands = append(commands, strings.Join(args, " "))
+					switch {
+					case slices.Contains(tt.failOn, args[0]):
+						return mockExecCommand(t, "", "Error: failed", 1)(name, args...)
+					case args[0] == "ls" && slices.Contains(args, "--sync=now"):
+						listing := after
+						if tt.after != "" {
+							listing = tt.after
+						}
+						return mockExecCommand(t, listing, "", 0)(name, args...)
+					case args[0] == "ls":
+						return mockExecCommand(t, before, "", 0)(name, args...)
+					}
+					cmd := mockExecCommand(t, "", "", 0)(name, args...)
+					if slices.Contains(args, "--name") {
+						rename = cmd
+					}
+					return cmd
+				},
+			}
+
+			// The original's modification time must not be checked against the copy.
+			id, err := ls.DuplicateEntry(context.Background(), tt.itemID, tt.opts)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("DuplicateEntry() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if id != tt.wantID {
+				t.Errorf("DuplicateEntry() = %q, want %q", id, tt.wantID)
+			}
+			if tt.wantCommands != nil && !reflect.DeepEqual(commands, tt.wantCommands) {
+				t.Errorf("DuplicateEntry() ran %q, want %q", commands, tt.wantCommands)
+			}
+			if tt.wantName != "" {
+				// The name is passed on stdin, which lpass has read by now.
+				stdin := rename.Stdin.(*strings.Reader)
+				_, _ = stdin.Seek(0, io.SeekStart)
+				got, _ := io.ReadAll(stdin)
+				if string(got) != tt.wantName {
+					t.Errorf("DuplicateEntry() renamed the copy to %q, want %q", got, tt.wantName)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,58 @@
+package lastpass
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// Errors returned by Service methods. Failed lpass commands are matched to
+// these by their output, so callers can tell them apart with errors.Is.
+var (
+	ErrNotLoggedIn = errors.New("not logged in to LastPass")
+	ErrNotFound    = errors.New("entry not found")
+	ErrNetwork     = errors.New("could not reach LastPass")
+	// ErrConflict is returned by write methods when the entry was changed
+	// after WriteOptions.IfUnmodifiedSince.
+	ErrConflict = errors.New("entry changed remotely")
+	// ErrNoLastModified is returned by write methods when WriteOptions has no
+	// modification time to check the entry against.
+	ErrNoLastModified = errors.New("entry modification time unknown")
+	// ErrWrongPassword and ErrMFARejected are returned by Login.
+	ErrWrongPassword = errors.New("wrong master password")
+	ErrMFARejected   = errors.New("multifactor authentication rejected")
+)
+
+// lpassError matches the stderr output of a failed lpass command to one of
+// the errors above. Unknown failures keep the lpass message.
+func lpassError(err error) error {
+	var exitErr *exec.ExitError
+	if !errors.As(err, &exitErr) {
+		return err
+	}
+
+	msg := strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(string(exitErr.Stderr)), "Error:"))
+	lower := strings.ToLower(msg)
+
+	var kind error
+	switch {
+	case strings.Contains(lower, "could not find decryption key"), strings.Contains(lower, "lpass login"):
+		kind = ErrNotLoggedIn
+	case strings.Contains(lower, "could not find specified account"):
+		kind = ErrNotFound
+	case strings.Contains(lower, "could not connect"), strings.Contains(lower, "unable to fetch blob"),
+		strings.Contains(lower, "timed out"), strings.Contains(lower, "curl"):
+		kind = ErrNetwork
+	}
+
+	switch {
+	case kind != nil && msg != "":
+		return fmt.Errorf("%w: %s", kind, msg)
+	case kind != nil:
+		return kind
+	case msg != "":
+		return fmt.Errorf("%w: %s", err, msg)
+	}
+	return err
+}
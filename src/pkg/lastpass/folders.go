@@ -0,0 +1,179 @@
+package lastpass
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/rwilgaard/alfred-lastpass-search/src/pkg/util"
+)
+
+// groupURL is the URL lpass gives the placeholder entries that make up folders.
+const groupURL = "http://group"
+
+// folderEntry is an entry found by folderEntries.
+type folderEntry struct {
+	ID    string
+	Share string
+	// Folder is the full folder path of the entry, without a trailing slash.
+	Folder string
+	// Placeholder entries only exist to keep a folder around.
+	Placeholder bool
+}
+
+// CreateFolder creates an empty folder by adding its placeholder entry.
+func (ls *Service) CreateFolder(ctx context.Context, folder string) error {
+	folder = strings.Trim(folder, "/")
+	if len(folder) == 0 {
+		return errors.New("folder is empty")
+	}
+
+	entries, err := ls.folderEntries(ctx, folder)
+	if err != nil {
+		return err
+	}
+	if len(entries) > 0 {
+		return fmt.Errorf("folder '%s' already exists", folder)
+	}
+
+	cmd := ls.command("add", "--non-interactive", "--sync=now", folder+"/")
+	if _, err := output(ctx, cmd, strings.NewReader("URL: "+groupURL+"\n")); err != nil {
+		return fmt.Errorf("error running lpass add for folder '%s': %w", folder, err)
+	}
+
+	return nil
+}
+
+// RenameFolder renames a folder by moving every entry in it and its subfolders.
+// If a move fails, the entries already moved are moved back.
+func (ls *Service) RenameFolder(ctx context.Context, from string, to string) error {
+	from, to = strings.Trim(from, "/"), strings.Trim(to, "/")
+	if len(from) == 0 || len(to) == 0 {
+		return errors.New("folder is empty")
+	}
+	if from == to {
+		return nil
+	}
+
+	entries, err := ls.folderEntries(ctx, from)
+	if err != nil {
+		return err
+	}
+	if len(entries) == 0 {
+		return fmt.Errorf("%w: folder '%s'", ErrNotFound, from)
+	}
+	for _, e := range entries {
+		if e.Share == from {
+			return fmt.Errorf("shared folder '%s' can't be renamed", from)
+		}
+	}
+	existing, err := ls.folderEntries(ctx, to)
+	if err != nil {
+		return err
+	}
+	if len(existing) > 0 {
+		return fmt.Errorf("folder '%s' already exists", to)
+	}
+
+	// Moving the entries of a folder leaves the entries themselves as they
+	// are, so the moves are not checked for conflicts.
+	var moved []folderEntry
+	for _, e := range entries {
+		target := to + strings.TrimPrefix(e.Folder, from)
+		if err := ls.moveEntry(ctx, e.ID, target); err != nil {
+			return errors.Join(err, ls.rollbackMoves(context.WithoutCancel(ctx), moved))
+		}
+		moved = append(moved, e)
+	}
+
+	return nil
+}
+
+// rollbackMoves moves entries back to their original folder.
+func (ls *Service) rollbackMoves(ctx context.Context, moved []folderEntry) error {
+	var errs []error
+	for _, e := range moved {
+		if err := ls.moveEntry(ctx, e.ID, e.Folder); err != nil {
+			errs = append(errs, fmt.Errorf("rolling back itemID '%s': %w", e.ID, err))
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("%d of %d moved entries could not be moved back: %w", len(errs), len(moved), errors.Join(errs...))
+	}
+	return nil
+}
+
+// DeleteEmptyFolder removes a folder and its subfolders. It refuses to delete
+// a folder that still contains entries.
+func (ls *Service) DeleteEmptyFolder(ctx context.Context, folder string) error {
+	folder = strings.Trim(folder, "/")
+	if len(folder) == 0 {
+		return errors.New("folder is empty")
+	}
+
+	entries, err := ls.folderEntries(ctx, folder)
+	if err != nil {
+		return err
+	}
+	if len(entries) == 0 {
+		return fmt.Errorf("%w: folder '%s'", ErrNotFound, folder)
+	}
+
+	var count int
+	for _, e := range entries {
+		if !e.Placeholder {
+			count++
+		}
+	}
+	if count > 0 {
+		return fmt.Errorf("folder '%s' is not empty, it has %d entries", folder, count)
+	}
+
+	// Only placeholders are left, which hold no data to lose.
+	for _, e := range entries {
+		if err := ls.deleteEntry(ctx, e.ID); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// folderEntries lists the entries in folder and its subfolders, including the
+// placeholders of the folders themselves.
+func (ls *Service) folderEntries(ctx context.Context, folder string) ([]folderEntry, error) {
+	cmd := ls.command("ls", "--sync=no", "--format", "[id: %ai] [share: %as] [group: %ag] [url: %al]")
+	out, err := output(ctx, cmd, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error running lpass ls for folder '%s': %w", folder, err)
+	}
+
+	shareRegex := regexp.MustCompile(`\[share: ([^\]]*)\]`)
+	groupRegex := regexp.MustCompile(`\[group: ([^\]]*)\]`)
+	urlRegex := regexp.MustCompile(`\[url: (.*)\]$`)
+
+	var entries []folderEntry
+	for _, l := range strings.Split(string(out), "\n") {
+		id := util.RegexSearch(entryIDRegex, l)
+		if id == "" {
+			continue
+		}
+
+		share := util.RegexSearch(shareRegex, l)
+		path := strings.TrimSuffix(folderName(share, util.RegexSearch(groupRegex, l)), "/")
+		if path != folder && !strings.HasPrefix(path, folder+"/") {
+			continue
+		}
+
+		entries = append(entries, folderEntry{
+			ID:          id,
+			Share:       share,
+			Folder:      path,
+			Placeholder: util.RegexSearch(urlRegex, l) == groupURL,
+		})
+	}
+
+	return entries, nil
+}
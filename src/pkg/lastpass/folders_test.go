@@ -0,0 +1,158 @@
+package lastpass
+
+import (
+	"context"
+	"os/exec"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const folderListing = `[id: 1] [share: ] [group: Work] [url: http://group]
+[id: 2] [share: ] [group: Work] [url: https://a.example.com]
+[id: 3] [share: ] [group: Work/Sub] [url: https://b.example.com]
+[id: 4] [share: ] [group: Workshop] [url: https://c.example.com]
+[id: 5] [share: ] [group: Empty] [url: http://group]
+[id: 6] [share: ] [group: Empty/Old] [url: http://group]
+[id: 7] [share: Shared-Team] [group: ] [url: https://d.example.com]
+`
+
+// folderService returns a Service that lists folderListing and records every
+// other command it runs. Commands starting with failOn exit with an error.
+func folderService(t *testing.T, failOn string, commands *[]string) *Service {
+	t.Helper()
+	return &Service{
+		BinPath: "lpass",
+		ExecCommand: func(name string, args ...string) *exec.Cmd {
+			if args[0] == "ls" {
+				return mockExecCommand(t, folderListing, "", 0)(name, args...)
+			}
+			command := strings.Join(args, " ")
+			*commands = append(*commands, command)
+			if failOn != "" && strings.HasPrefix(command, failOn) {
+				return mockExecCommand(t, "", "Error: failed", 1)(name, args...)
+			}
+			return mockExecCommand(t, "", "", 0)(name, args...)
+		},
+	}
+}
+
+func TestLastpassServiceCreateFolder(t *testing.T) {
+	testCases := []struct {
+		name         string
+		folder       string
+		wantCommands []string
+		wantErr      bool
+	}{
+		{name: "New folder", folder: "Staging/", wantCommands: []string{"add --non-interactive --sync=now Staging/"}},
+		{name: "Existing folder", folder: "Work", wantErr: true},
+		{name: "Empty name", folder: "/", wantErr: true},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			var commands []string
+			err := folderService(t, "", &commands).CreateFolder(context.Background(), tt.folder)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("CreateFolder() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(commands, tt.wantCommands) {
+				t.Errorf("CreateFolder() ran %q, want %q", commands, tt.wantCommands)
+			}
+		})
+	}
+}
+
+func TestLastpassServiceRenameFolder(t *testing.T) {
+	testCases := []struct {
+		name         string
+		from         string
+		to           string
+		failOn       string
+		wantCommands []string
+		wantErr      bool
+	}{
+		{
+			name: "Moves subfolders but not folders with the same prefix",
+			from: "Work/",
+			to:   "Office",
+			wantCommands: []string{
+				"mv --sync=now 1 Office",
+				"mv --sync=now 2 Office",
+				"mv --sync=now 3 Office/Sub",
+			},
+		},
+		{
+			name:   "Failed move rolls back",
+			from:   "Work",
+			to:     "Office",
+			failOn: "mv --sync=now 3",
+			wantCommands: []string{
+				"mv --sync=now 1 Office",
+				"mv --sync=now 2 Office",
+				"mv --sync=now 3 Office/Sub",
+				"mv --sync=now 1 Work",
+				"mv --sync=now 2 Work",
+			},
+			wantErr: true,
+		},
+		{name: "Target exists", from: "Work", to: "Empty", wantErr: true},
+		{name: "Unknown folder", from: "Nope", to: "Office", wantErr: true},
+		{name: "Shared folder", from: "Shared-Team", to: "Shared-Other", wantErr: true},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			var commands []string
+			err := folderService(t, tt.failOn, &commands).RenameFolder(context.Background(), tt.from, tt.to)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("RenameFolder() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(commands, tt.wantCommands) {
+				t.Errorf("RenameFolder() ran %q, want %q", commands, tt.wantCommands)
+			}
+		})
+	}
+}
+
+func TestLastpassServiceDeleteEmptyFolder(t *testing.T) {
+	testCases := []struct {
+		name            string
+		folder          string
+		wantCommands    []string
+		expectedErrText string
+	}{
+		{
+			name:         "Empty folder with an empty subfolder",
+			folder:       "Empty",
+			wantCommands: []string{"rm --sync=now 5", "rm --sync=now 6"},
+		},
+		{
+			name:            "Folder with entries",
+			folder:          "Work",
+			expectedErrText: "folder 'Work' is not empty, it has 2 entries",
+		},
+		{
+			name:            "Unknown folder",
+			folder:          "Nope",
+			expectedErrText: "entry not found: folder 'Nope'",
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			var commands []string
+			err := folderService(t, "", &commands).DeleteEmptyFolder(context.Background(), tt.folder)
+			if tt.expectedErrText != "" {
+				if err == nil || err.Error() != tt.expectedErrText {
+					t.Errorf("DeleteEmptyFolder() error = %v, want %q", err, tt.expectedErrText)
+				}
+			} else if err != nil {
+				t.Fatalf("DeleteEmptyFolder() unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(commands, tt.wantCommands) {
+				t.Errorf("DeleteEmptyFolder() ran %q, want %q", commands, tt.wantCommands)
+			}
+		})
+	}
+}
@@ -3,9 +3,13 @@ package lastpass
 import (
 	"errors"
 	"fmt"
+	"os"
 	"os/exec"
+	"path"
 	"regexp"
+	"sort"
 	"strings"
+	"time"
 
 	"github.com/rwilgaard/alfred-lastpass-search/src/pkg/util"
 )
@@ -14,21 +18,74 @@ import (
 type Service struct {
 	BinPath     string
 	ExecCommand func(name string, arg ...string) *exec.Cmd
+	// Env holds extra environment variables for lpass, like LPASS_HOME.
+	Env []string
+	// SyncFile records the time of the last successful Sync. Optional.
+	SyncFile string
 }
 
 type Folder struct {
-	Name string
+	Name         string
+	EntryCount   int
+	LastModified time.Time
+	Shared       bool
+	// Permission is our permission in a shared folder. It is not set by
+	// GetFolders, see SetSharePermissions.
+	Permission string
+}
+
+// Share returns the shared folder the folder belongs to.
+func (f Folder) Share() string {
+	share, _, _ := strings.Cut(f.Name, "/")
+	return share
+}
+
+// Subtitle describes the folder for the folder lists.
+func (f Folder) Subtitle(now time.Time) string {
+	parts := []string{fmt.Sprintf("%d entries", f.EntryCount)}
+	if f.EntryCount == 1 {
+		parts[0] = "1 entry"
+	}
+	if !f.LastModified.IsZero() {
+		parts = append(parts, "modified "+util.RelativeTime(f.LastModified, now))
+	}
+	if f.Shared {
+		if f.Permission != "" {
+			parts = append(parts, fmt.Sprintf("Shared (%s)", f.Permission))
+		} else {
+			parts = append(parts, "Shared")
+		}
+	}
+	return strings.Join(parts, "  •  ")
 }
 
 type Entry struct {
-	ID       string
-	Name     string
-	Folder   string
-	URL      string
-	Username string
-	Password string
+	ID           string
+	Name         string
+	Folder       string
+	URL          string
+	Username     string
+	Password     string
+	LastModified time.Time
+	// LastModifiedPrecision is how precisely LastModified is known. Zero means
+	// to the minute, as GetEntries reads it from lpass ls.
+	LastModifiedPrecision time.Duration
+	LastUsed              time.Time
+	// HasTOTP tells whether the entry has a TOTP secret. It is not set by
+	// GetEntries, see EntriesWithField.
+	HasTOTP bool
 }
 
+// Sort orders accepted by SortEntries.
+const (
+	SortName           = "name"
+	SortRecentUsed     = "recent-used"
+	SortRecentModified = "recent-modified"
+)
+
+// lpassTimeLayout is the layout lpass uses for the %am and %aU format specifiers.
+const lpassTimeLayout = "2006-01-02 15:04"
+
 // NewService creates a new Service.
 func NewService(binPath string) (*Service, error) {
 	if len(binPath) == 0 {
@@ -43,42 +100,108 @@ func NewService(binPath string) (*Service, error) {
 	return svc, nil
 }
 
+// command creates an lpass command with the extra environment of the Service.
+func (ls *Service) command(arg ...string) *exec.Cmd {
+	cmd := ls.ExecCommand(ls.BinPath, arg...)
+	if len(ls.Env) > 0 {
+		if cmd.Env == nil {
+			cmd.Env = os.Environ()
+		}
+		cmd.Env = append(cmd.Env, ls.Env...)
+	}
+	return cmd
+}
+
+// getenv returns an environment variable as lpass sees it.
+func (ls *Service) getenv(key string) string {
+	for i := len(ls.Env) - 1; i >= 0; i-- {
+		if v, ok := strings.CutPrefix(ls.Env[i], key+"="); ok {
+			return v
+		}
+	}
+	return os.Getenv(key)
+}
+
 // IsLoggedIn checks if the user is logged into LastPass.
 func (ls *Service) IsLoggedIn() bool {
-	cmd := ls.ExecCommand(ls.BinPath, "status", "--quiet")
+	cmd := ls.command("status", "--quiet")
 	err := cmd.Run()
 
 	return err == nil
 }
 
-// GetFolders retrieves all LastPass folder names.
+// GetFolders retrieves all LastPass folders with their entry count, last
+// modification time and whether they are shared. Everything is computed from a
+// single lpass ls pass.
 func (ls *Service) GetFolders() ([]Folder, error) {
-	cmdString := ls.BinPath + ` ls --format="%/as%/ag" --sync=no | sort -u`
-	cmd := ls.ExecCommand("bash", "-c", cmdString)
+	cmd := ls.command("ls", "--format", "[share: %as] [group: %ag] [url: %al] [modified: %am]", "--sync=no")
 	out, err := cmd.Output()
 	if err != nil {
 		return nil, fmt.Errorf("error running command to get folders: %w", err)
 	}
 
-	var folders []Folder
-	folderOutput := strings.TrimSuffix(string(out), "\n")
-	if folderOutput == "" {
-		return []Folder{}, nil
-	}
-	for _, folderName := range strings.Split(folderOutput, "\n") {
-		if folderName != "" { // Ensure we don't add empty folder names
-			lf := Folder{
-				Name: folderName,
-			}
-			folders = append(folders, lf)
+	shareRegex := regexp.MustCompile(`\[share: ([^\]]*)\]`)
+	groupRegex := regexp.MustCompile(`\[group: ([^\]]*)\]`)
+	urlRegex := regexp.MustCompile(`\[url: (.*?)\] \[modified:`)
+	modifiedRegex := regexp.MustCompile(`\[modified: ([^\]]*)\]`)
+
+	byName := make(map[string]*Folder)
+	for _, l := range strings.Split(string(out), "\n") {
+		if l == "" {
+			continue
 		}
+
+		share := util.RegexSearch(shareRegex, l)
+		group := util.RegexSearch(groupRegex, l)
+		name := folderName(share, group)
+		if name == "" {
+			// Entries without a folder
+			continue
+		}
+
+		f, ok := byName[name]
+		if !ok {
+			f = &Folder{Name: name, Shared: share != ""}
+			byName[name] = f
+		}
+
+		if util.RegexSearch(urlRegex, l) == "http://group" {
+			// Folder placeholder entries are not counted
+			continue
+		}
+
+		f.EntryCount++
+		if modified := parseTime(util.RegexSearch(modifiedRegex, l), time.UTC); modified.After(f.LastModified) {
+			f.LastModified = modified
+		}
+	}
+
+	folders := make([]Folder, 0, len(byName))
+	for _, f := range byName {
+		folders = append(folders, *f)
 	}
+	sort.Slice(folders, func(i, j int) bool {
+		return folders[i].Name < folders[j].Name
+	})
 
 	return folders, nil
 }
 
+// folderName builds the folder name the way lpass prints it with "%/as%/ag".
+func folderName(share string, group string) string {
+	var name string
+	if share != "" {
+		name += share + "/"
+	}
+	if group != "" {
+		name += group + "/"
+	}
+	return name
+}
+
 // GetEntries retrieves LastPass entries, optionally filtered by query and folders.
-func (ls *Service) GetEntries(query string, folders []string, fuzzy bool) ([]Entry, error) { //nolint:revive // Allow control flag for fuzzy search
+// Entries in folders matching one of the exclude globs are dropped after the listing.
+func (ls *Service) GetEntries(query string, folders []string, exclude []string, fuzzy bool) ([]Entry, error) { //nolint:revive // Allow control flag for fuzzy search
 	var outputBuilder strings.Builder
 
 	if len(folders) == 0 {
@@ -86,7 +209,7 @@ func (ls *Service) GetEntries(query string, folders []string, fuzzy bool) ([]Ent
 	}
 
 	for _, folder := range folders {
-		cmd := ls.ExecCommand(ls.BinPath, "ls", "--format", "%aN [id: %ai] [url: %al] [username: %au] %ap", "--sync=no", folder)
+		cmd := ls.command("ls", "--format", "%aN [id: %ai] [modified: %am] [used: %aU] [url: %al] [username: %au] %ap", "--sync=no", folder)
 		out, err := cmd.Output()
 		if err != nil {
 			return nil, fmt.Errorf("error running lpass ls for folder '%s': %w", folder, err)
@@ -105,6 +228,8 @@ func (ls *Service) GetEntries(query string, folders []string, fuzzy bool) ([]Ent
 	folderRegex := regexp.MustCompile(`^(.*?)\/`)
 	usernameRegex := regexp.MustCompile(`\[username: (.+?)\]`)
 	passwordRegex := regexp.MustCompile(`.*\] (.*)$`)
+	modifiedRegex := regexp.MustCompile(`\[modified: ([^\]]*)\]`)
+	usedRegex := regexp.MustCompile(`\[used: ([^\]]*)\]`)
 
 	entries := make([]Entry, 0)
 	trimmedOutput := strings.TrimSuffix(fullOutput, "\n")
@@ -123,6 +248,9 @@ func (ls *Service) GetEntries(query string, folders []string, fuzzy bool) ([]Ent
 		url := util.RegexSearch(urlRegex, l)
 		username := util.RegexSearch(usernameRegex, l)
 		password := util.RegexSearch(passwordRegex, l)
+		// lpass prints the modification time in UTC and the last touch time in local time.
+		lastModified := parseTime(util.RegexSearch(modifiedRegex, l), time.UTC)
+		lastUsed := parseTime(util.RegexSearch(usedRegex, l), time.Local)
 
 		if id == "" {
 			// Skip entries without an ID
@@ -134,36 +262,110 @@ func (ls *Service) GetEntries(query string, folders []string, fuzzy bool) ([]Ent
 			continue
 		}
 
-		if !fuzzy && query != "" {
-			searchableString := fmt.Sprintf("%s %s %s %s %s", id, name, folder, url, username)
-			if !util.HasAll(strings.ToLower(searchableString), strings.Split(strings.ToLower(query), " ")) {
-				continue
-			}
+		if len(exclude) > 0 && MatchFolder(exclude, folderPath(folder, name)) {
+			continue
 		}
 
-		entries = append(entries, Entry{
-			ID:       id,
-			Name:     name,
-			Folder:   folder,
-			URL:      url,
-			Username: username,
-			Password: password,
-		})
+		e := Entry{
+			ID:           id,
+			Name:         name,
+			Folder:       folder,
+			URL:          url,
+			Username:     username,
+			Password:     password,
+			LastModified: lastModified,
+			LastUsed:     lastUsed,
+		}
+		if !fuzzy && !e.Matches(query) {
+			continue
+		}
+		entries = append(entries, e)
 	}
 
 	return entries, nil
 }
 
+// Matches reports whether every word of query is in the ID, name, folder, URL
+// or username of the entry. An empty query matches every entry.
+func (e Entry) Matches(query string) bool {
+	if query == "" {
+		return true
+	}
+	searchableString := fmt.Sprintf("%s %s %s %s %s", e.ID, e.Name, e.Folder, e.URL, e.Username)
+	return util.HasAll(strings.ToLower(searchableString), strings.Split(strings.ToLower(query), " "))
+}
+
+// WriteOptions returns the options that make write methods refuse to change
+// the entry if it was modified since this copy of it was read.
+func (e Entry) WriteOptions() WriteOptions {
+	precision := e.LastModifiedPrecision
+	if precision == 0 {
+		precision = time.Minute
+	}
+	return WriteOptions{IfUnmodifiedSince: e.LastModified, Precision: precision}
+}
+
+// FolderPath returns the full folder path of the entry, including any subfolders.
+func (e Entry) FolderPath() string {
+	return folderPath(e.Folder, e.Name)
+}
+
+// folderPath joins the top-level folder with any subfolders that lpass prints as part of the name.
+func folderPath(folder string, name string) string {
+	if folder == "" {
+		return ""
+	}
+	return path.Dir(folder + "/" + name)
+}
+
+// SortEntries sorts entries in place by the given order. An empty order keeps the lpass order.
+func SortEntries(entries []Entry, order string) error {
+	return SortEntriesFunc(entries, order, func(e Entry) Entry { return e })
+}
+
+// SortEntriesFunc sorts items in place by the given order of the entry each
+// of them holds. An empty order keeps the lpass order.
+func SortEntriesFunc[T any](items []T, order string, entry func(T) Entry) error {
+	switch order {
+	case "":
+		return nil
+	case SortName:
+		sort.SliceStable(items, func(i, j int) bool {
+			return strings.ToLower(entry(items[i]).Name) < strings.ToLower(entry(items[j]).Name)
+		})
+	case SortRecentUsed:
+		sort.SliceStable(items, func(i, j int) bool {
+			return entry(items[i]).LastUsed.After(entry(items[j]).LastUsed)
+		})
+	case SortRecentModified:
+		sort.SliceStable(items, func(i, j int) bool {
+			return entry(items[i]).LastModified.After(entry(items[j]).LastModified)
+		})
+	default:
+		return fmt.Errorf("unknown sort order '%s'", order)
+	}
+	return nil
+}
+
+// parseTime parses a timestamp printed by lpass. Empty or invalid values return the zero time.
+func parseTime(value string, loc *time.Location) time.Time {
+	t, err := time.ParseInLocation(lpassTimeLayout, value, loc)
+	if err != nil {
+		return time.Time{}
+	}
+	return t
+}
+
 // GetDetails retrieves detailed information for a specific LastPass item.
 func (ls *Service) GetDetails(itemID string) ([]string, map[string]string, error) {
 	if len(itemID) == 0 {
 		return nil, nil, errors.New("itemID is empty")
 	}
 
-	cmd := ls.ExecCommand(ls.BinPath, "show", itemID, "--sync=no")
+	cmd := ls.command("show", itemID, "--sync=no")
 	out, err := cmd.Output()
 	if err != nil {
-		return nil, nil, fmt.Errorf("error running lpass show for itemID '%s': %w", itemID, err)
+		return nil, nil, fmt.Errorf("error running lpass show for itemID '%s': %w", itemID, lpassError(err))
 	}
 
 	keyRegex := regexp.MustCompile(`^(\S.+?):`)
@@ -193,12 +395,66 @@ func (ls *Service) GetDetails(itemID string) ([]string, map[string]string, error
 	return keys, details, nil
 }
 
-// CheckValidity checks if an action can be performed on an entry.
-func (ls *Service) CheckValidity(entry Entry, action string) bool {
-	if action == "Copy Password" && entry.Password == "" {
-		return false
-	} else if action == "Copy Username" && entry.Username == "" {
-		return false
+// EntriesWithField returns which of ids have a non-empty field named like one
+// of names, compared without case. All entries are read with a single lpass
+// show.
+func (ls *Service) EntriesWithField(ids []string, names []string) (map[string]bool, error) {
+	found := make(map[string]bool)
+	if len(ids) == 0 {
+		return found, nil
+	}
+
+	cmd := ls.command(append([]string{"show", "--sync=no", "--color=never"}, ids...)...)
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("error running lpass show: %w", lpassError(err))
+	}
+
+	headerRegex := regexp.MustCompile(`\[id: ([0-9]+)\]$`)
+	fieldRegex := regexp.MustCompile(`^(\S.*?): (.+)`)
+	var id string
+	for _, l := range strings.Split(string(out), "\n") {
+		if m := headerRegex.FindStringSubmatch(l); m != nil {
+			id = m[1]
+			continue
+		}
+		m := fieldRegex.FindStringSubmatch(l)
+		if id == "" || m == nil {
+			continue
+		}
+		for _, name := range names {
+			if strings.EqualFold(m[1], name) {
+				found[id] = true
+			}
+		}
+	}
+	return found, nil
+}
+
+// GetField retrieves a single field of an entry. The standard fields password,
+// username, url, notes and id are passed as flags, anything else is looked up
+// as a custom field.
+func (ls *Service) GetField(itemID string, field string) (string, error) {
+	if len(itemID) == 0 {
+		return "", errors.New("itemID is empty")
+	}
+
+	cmd := ls.command("show", "--sync=no", fieldArg(field, "password", "username", "url", "notes", "id"), itemID)
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("error running lpass show for field '%s' of itemID '%s': %w", field, itemID, lpassError(err))
+	}
+
+	return strings.TrimSuffix(string(out), "\n"), nil
+}
+
+// fieldArg returns the lpass argument that selects field. Fields listed in
+// standard have their own flag, anything else is passed with --field.
+func fieldArg(field string, standard ...string) string {
+	for _, s := range standard {
+		if strings.EqualFold(field, s) {
+			return "--" + s
+		}
 	}
-	return true
+	return "--field=" + field
 }
@@ -1,12 +1,15 @@
 package lastpass
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"os"
 	"os/exec"
 	"reflect"
 	"strings"
 	"testing"
+	"time"
 )
 
 // TestHelperProcess isn't a real test. It's used as a helper process
@@ -125,47 +128,50 @@ func TestLastpassServiceGetFolders(t *testing.T) {
 			wantErr:      false,
 		},
 		{
-			name:         "Single folder",
-			mockStdout:   "Work/\n",
+			name:         "Entries without a folder are skipped",
+			mockStdout:   "[share: ] [group: ] [url: http://example.com] [modified: 2024-01-01 10:00]\n",
 			mockStderr:   "",
 			mockExitCode: 0,
-			wantFolders: []Folder{
-				{Name: "Work/"},
-			},
-			wantErr: false,
+			wantFolders:  []Folder{},
+			wantErr:      false,
 		},
 		{
-			name:         "Multiple folders",
-			mockStdout:   "Personal/\nShared-Family/\nWork/\n",
+			name: "Counts entries and tracks last modification",
+			mockStdout: "[share: ] [group: Work] [url: http://a.com] [modified: 2024-01-01 10:00]\n" +
+				"[share: ] [group: Work] [url: http://b.com] [modified: 2024-02-01 09:30]\n" +
+				"[share: ] [group: Personal] [url: http://c.com] [modified: ]\n",
 			mockStderr:   "",
 			mockExitCode: 0,
 			wantFolders: []Folder{
-				{Name: "Personal/"},
-				{Name: "Shared-Family/"},
-				{Name: "Work/"},
+				{Name: "Personal/", EntryCount: 1},
+				{Name: "Work/", EntryCount: 2, LastModified: time.Date(2024, 2, 1, 9, 30, 0, 0, time.UTC)},
 			},
 			wantErr: false,
 		},
 		{
-			name:         "Folders with spaces and special characters",
-			mockStdout:   "Finance/Banking/\nOld Projects/\nWork Items (New)/\n",
+			name: "Empty folder placeholders are listed but not counted",
+			mockStdout: "[share: ] [group: Old Projects] [url: http://group] [modified: 2023-05-05 05:05]\n" +
+				"[share: ] [group: Work Items (New)] [url: http://group] [modified: ]\n" +
+				"[share: ] [group: Work Items (New)] [url: http://x.com] [modified: 2024-01-01 00:00]\n",
 			mockStderr:   "",
 			mockExitCode: 0,
 			wantFolders: []Folder{
-				{Name: "Finance/Banking/"},
 				{Name: "Old Projects/"},
-				{Name: "Work Items (New)/"},
+				{Name: "Work Items (New)/", EntryCount: 1, LastModified: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)},
 			},
 			wantErr: false,
 		},
 		{
-			name:         "Output with trailing newline already handled by TrimSuffix",
-			mockStdout:   "Folder1/\nFolder2/\n",
+			name: "Shared folders and subfolders",
+			mockStdout: "[share: Shared-Family] [group: ] [url: http://netflix.com] [modified: ]\n" +
+				"[share: Shared-Family] [group: Banking] [url: http://bank.com] [modified: ]\n" +
+				"[share: ] [group: Finance/Banking] [url: http://bank2.com] [modified: ]\n",
 			mockStderr:   "",
 			mockExitCode: 0,
 			wantFolders: []Folder{
-				{Name: "Folder1/"},
-				{Name: "Folder2/"},
+				{Name: "Finance/Banking/", EntryCount: 1},
+				{Name: "Shared-Family/", EntryCount: 1, Shared: true},
+				{Name: "Shared-Family/Banking/", EntryCount: 1, Shared: true},
 			},
 			wantErr: false,
 		},
@@ -226,6 +232,7 @@ func TestLastpassServiceGetEntries(t *testing.T) {
 	type args struct {
 		query   string
 		folders []string
+		exclude []string
 		fuzzy   bool
 	}
 	testCases := []struct {
@@ -310,6 +317,67 @@ func TestLastpassServiceGetEntries(t *testing.T) {
 			},
 			wantErr: false,
 		},
+		{
+			name: "Entry with modified and used timestamps",
+			fields: fields{
+				BinPath: "lpass",
+			},
+			args: args{
+				query:   "",
+				folders: []string{},
+				fuzzy:   false,
+			},
+			mockStdout:   "Work/GitHub [id: 555] [modified: 2024-03-01 12:30] [used: 2024-03-02 08:15] [url: https://github.com] [username: octocat] ghpass\n",
+			mockStderr:   "",
+			mockExitCode: 0,
+			want: []Entry{
+				{
+					ID: "555", Name: "GitHub", Folder: "Work", URL: "https://github.com", Username: "octocat", Password: "ghpass",
+					LastModified: time.Date(2024, 3, 1, 12, 30, 0, 0, time.UTC),
+					LastUsed:     time.Date(2024, 3, 2, 8, 15, 0, 0, time.Local),
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "Entry with empty timestamps",
+			fields: fields{
+				BinPath: "lpass",
+			},
+			args: args{
+				query:   "",
+				folders: []string{},
+				fuzzy:   false,
+			},
+			mockStdout:   "Work/Never Used [id: 556] [modified: ] [used: ] [url: https://example.com] [username: user] pass\n",
+			mockStderr:   "",
+			mockExitCode: 0,
+			want: []Entry{
+				{ID: "556", Name: "Never Used", Folder: "Work", URL: "https://example.com", Username: "user", Password: "pass"},
+			},
+			wantErr: false,
+		},
+		{
+			name: "Excluded folders are dropped",
+			fields: fields{
+				BinPath: "lpass",
+			},
+			args: args{
+				query:   "",
+				folders: []string{},
+				exclude: []string{"Archive*", "Shared-Team/Old"},
+				fuzzy:   false,
+			},
+			mockStdout: "Archive 2019/Old Site [id: 1] [url: http://old.com] [username: u1] p1\n" +
+				"Shared-Team/Old/Legacy DB [id: 2] [url: http://db.com] [username: u2] p2\n" +
+				"Shared-Team/Current DB [id: 3] [url: http://db2.com] [username: u3] p3\n",
+			mockStderr:   "",
+			mockExitCode: 0,
+			want: []Entry{
+				{ID: "3", Name: "Current DB", Folder: "Shared-Team", URL: "http://db2.com", Username: "u3", Password: "p3"},
+			},
+			wantErr: false,
+		},
 	}
 	for _, tt := range testCases {
 		t.Run(tt.name, func(t *testing.T) {
@@ -321,7 +389,7 @@ func TestLastpassServiceGetEntries(t *testing.T) {
 				// TestHelperProcess will receive the full command including the folder.
 				ExecCommand: mockExecCommand(t, tt.mockStdout, tt.mockStderr, tt.mockExitCode),
 			}
-			got, err := ls.GetEntries(tt.args.query, tt.args.folders, tt.args.fuzzy)
+			got, err := ls.GetEntries(tt.args.query, tt.args.folders, tt.args.exclude, tt.args.fuzzy)
 
 			if (err != nil) != tt.wantErr {
 				t.Errorf("GetEntries() error = %v, wantErr %v", err, tt.wantErr)
@@ -508,3 +576,245 @@ Notes: Some notes.
 		})
 	}
 }
+
+func TestSortEntries(t *testing.T) {
+	older := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	newer := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	entries := func() []Entry {
+		return []Entry{
+			{ID: "1", Name: "beta", LastModified: newer, LastUsed: older},
+			{ID: "2", Name: "Alpha", LastModified: older, LastUsed: newer},
+			{ID: "3", Name: "gamma"},
+		}
+	}
+
+	testCases := []struct {
+		name    string
+		order   string
+		wantIDs []string
+		wantErr bool
+	}{
+		{name: "Keep lpass order", order: "", wantIDs: []string{"1", "2", "3"}},
+		{name: "By name", order: SortName, wantIDs: []string{"2", "1", "3"}},
+		{name: "Recently used", order: SortRecentUsed, wantIDs: []string{"2", "1", "3"}},
+		{name: "Recently modified", order: SortRecentModified, wantIDs: []string{"1", "2", "3"}},
+		{name: "Unknown order", order: "size", wantErr: true},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			got := entries()
+			err := SortEntries(got, tt.order)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("SortEntries() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			var gotIDs []string
+			for _, e := range got {
+				gotIDs = append(gotIDs, e.ID)
+			}
+			if !reflect.DeepEqual(gotIDs, tt.wantIDs) {
+				t.Errorf("SortEntries() order = %v, want %v", gotIDs, tt.wantIDs)
+			}
+		})
+	}
+}
+
+func TestLastpassServiceEntriesWithField(t *testing.T) {
+	show := `Work/GitHub [id: 100]
+Username: me
+Password: pw
+TOTP: JBSWY3DPEHPK3PXP
+URL: https://github.com
+Work/Mail [id: 200]
+Username: me
+Password: pw
+Notes: no totp: here
+Personal/Bank [id: 300]
+totp secret: JBSWY3DPEHPK3PXP
+`
+	ls := &Service{
+		BinPath:     "lpass",
+		ExecCommand: mockExecCommand(t, show, "", 0),
+	}
+
+	got, err := ls.EntriesWithField([]string{"100", "200", "300"}, []string{"totp", "totp secret"})
+	if err != nil {
+		t.Fatalf("EntriesWithField() unexpected error: %v", err)
+	}
+	if want := map[string]bool{"100": true, "300": true}; !reflect.DeepEqual(got, want) {
+		t.Errorf("EntriesWithField() = %v, want %v", got, want)
+	}
+
+	ls.ExecCommand = mockExecCommand(t, "", "Error: Could not find decryption key.", 1)
+	if _, err := ls.EntriesWithField([]string{"100"}, []string{"totp"}); err == nil {
+		t.Errorf("EntriesWithField() expected error, got nil")
+	}
+}
+
+func TestEntryMatches(t *testing.T) {
+	e := Entry{ID: "42", Name: "Mail", Folder: "Work", URL: "https://mail.example.com", Username: "me"}
+
+	testCases := []struct {
+		query string
+		want  bool
+	}{
+		{query: "", want: true},
+		{query: "mail work", want: true},
+		{query: "42", want: true},
+		{query: "mail personal", want: false},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.query, func(t *testing.T) {
+			if got := e.Matches(tt.query); got != tt.want {
+				t.Errorf("Matches(%q) = %v, want %v", tt.query, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFolderSubtitle(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	folders := []Folder{
+		{Name: "Work", EntryCount: 1},
+		{Name: "Shared-Team", EntryCount: 3, Shared: true},
+		{Name: "Shared-Team/Servers", EntryCount: 2, Shared: true},
+		{Name: "Shared-Other", EntryCount: 2, Shared: true},
+	}
+	SetSharePermissions(folders, map[string]string{
+		"Shared-Team": PermissionReadOnly,
+		"Work":        PermissionAdmin,
+	})
+
+	want := []string{
+		"1 entry",
+		"3 entries  •  Shared (" + PermissionReadOnly + ")",
+		"2 entries  •  Shared (" + PermissionReadOnly + ")",
+		"2 entries  •  Shared",
+	}
+	for i, f := range folders {
+		if got := f.Subtitle(now); got != want[i] {
+			t.Errorf("Subtitle() of %s = %q, want %q", f.Name, got, want[i])
+		}
+	}
+}
+
+func TestLastpassServiceGetShareUsers(t *testing.T) {
+	userls := `User                                             RO  Admin   Hide OutEnt Accept
+Jane Doe <jane@example.com>                       _      x      _      _      x
+john@example.com                                  x      _      x      _      _
+`
+	ls := &Service{
+		BinPath:     "lpass",
+		ExecCommand: mockExecCommand(t, userls, "", 0),
+	}
+
+	got, err := ls.GetShareUsers(context.Background(), "Shared-Team/")
+	if err != nil {
+		t.Fatalf("GetShareUsers() error = %v", err)
+	}
+
+	want := []ShareUser{
+		{Name: "Jane Doe", Username: "jane@example.com", Admin: true, Accepted: true},
+		{Name: "john@example.com", Username: "john@example.com", ReadOnly: true, HidePasswords: true},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("GetShareUsers() = %+v, want %+v", got, want)
+	}
+
+	if p := SharePermission(got, "JANE@example.com"); p != PermissionAdmin {
+		t.Errorf("SharePermission(jane) = %q, want %q", p, PermissionAdmin)
+	}
+	if p := SharePermission(got, "john@example.com"); p != PermissionReadOnly {
+		t.Errorf("SharePermission(john) = %q, want %q", p, PermissionReadOnly)
+	}
+	if p := SharePermission(got, "nobody@example.com"); p != "" {
+		t.Errorf("SharePermission(nobody) = %q, want empty", p)
+	}
+
+	if _, err := ls.GetShareUsers(context.Background(), ""); err == nil {
+		t.Errorf("GetShareUsers() with empty share expected an error, got nil")
+	}
+
+	ls.ExecCommand = mockExecCommand(t, "", "Error: Could not find decryption key. Perhaps you need to login with `lpass login`.", 1)
+	if _, err := ls.GetShareUsers(context.Background(), "Shared-Team"); !errors.Is(err, ErrNotLoggedIn) {
+		t.Errorf("GetShareUsers() logged out error = %v, want %v", err, ErrNotLoggedIn)
+	}
+}
+
+func TestLastpassServiceGetField(t *testing.T) {
+	testCases := []struct {
+		name            string
+		itemID          string
+		field           string
+		mockStdout      string
+		mockExitCode    int
+		want            string
+		wantErr         bool
+		expectedErrText string
+	}{
+		{
+			name:       "Password",
+			itemID:     "123",
+			field:      "password",
+			mockStdout: "s3cret\n",
+			want:       "s3cret",
+		},
+		{
+			name:       "Multi-line notes keep inner newlines",
+			itemID:     "123",
+			field:      "notes",
+			mockStdout: "line one\nline two\n",
+			want:       "line one\nline two",
+		},
+		{
+			name:       "Custom field",
+			itemID:     "123",
+			field:      "API Key",
+			mockStdout: "abc\n",
+			want:       "abc",
+		},
+		{
+			name:            "Command fails",
+			itemID:          "123",
+			field:           "username",
+			mockExitCode:    1,
+			wantErr:         true,
+			expectedErrText: "error running lpass show for field 'username'",
+		},
+		{
+			name:            "Empty itemID",
+			itemID:          "",
+			field:           "password",
+			wantErr:         true,
+			expectedErrText: "itemID is empty",
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			ls := &Service{
+				BinPath:     "lpass",
+				ExecCommand: mockExecCommand(t, tt.mockStdout, "", tt.mockExitCode),
+			}
+
+			got, err := ls.GetField(tt.itemID, tt.field)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("GetField() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				if !strings.Contains(err.Error(), tt.expectedErrText) {
+					t.Errorf("GetField() error = %v, want error containing %q", err, tt.expectedErrText)
+				}
+				return
+			}
+			if got != tt.want {
+				t.Errorf("GetField() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,160 @@
+package lastpass
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// PromptLogEnv names the file an askpass helper appends each prompt it
+// answers to, one per line. Login reads it to tell which prompts lpass showed.
+const PromptLogEnv = "LPASS_PROMPT_LOG"
+
+// PromptKind is the kind of secret lpass asks for during login.
+type PromptKind string
+
+// Prompt kinds returned by ParsePrompt.
+const (
+	PromptPassword  PromptKind = "password"
+	PromptOTP       PromptKind = "otp"
+	PromptOutOfBand PromptKind = "out-of-band"
+	PromptTrust     PromptKind = "trust"
+	PromptOther     PromptKind = "other"
+	promptNone      PromptKind = ""
+)
+
+// LoginOptions configure Login.
+type LoginOptions struct {
+	// AskPass is the program lpass runs to ask for the master password and
+	// multifactor codes. It gets the prompt as its only argument and writes
+	// the answer to stdout.
+	AskPass string
+	// Trust makes LastPass skip multifactor authentication on this device
+	// from now on.
+	Trust bool
+}
+
+// LoginResult describes a successful login.
+type LoginResult struct {
+	// Prompts are the prompts lpass showed, in order.
+	Prompts []PromptKind
+	// Message is the success message of lpass, like "Logged in as user@example.com."
+	Message string
+}
+
+// ParsePrompt returns the kind of a prompt lpass passes to the askpass helper
+// or prints while logging in.
+func ParsePrompt(prompt string) PromptKind {
+	lower := strings.ToLower(strings.TrimSpace(prompt))
+	switch {
+	case lower == "":
+		return promptNone
+	case strings.Contains(lower, "master password"):
+		return PromptPassword
+	case strings.Contains(lower, "out-of-band"):
+		return PromptOutOfBand
+	case strings.Contains(lower, "trust"):
+		return PromptTrust
+	case lower == "code", strings.Contains(lower, "otp"), strings.Contains(lower, "passcode"),
+		strings.Contains(lower, "authenticator"), strings.Contains(lower, "yubikey"):
+		return PromptOTP
+	}
+	return PromptOther
+}
+
+// Login logs username in to LastPass. lpass asks opts.AskPass for the master
+// password and any multifactor code. Failures are ErrWrongPassword,
+// ErrMFARejected or ErrNetwork where they can be told apart.
+func (ls *Service) Login(ctx context.Context, username string, opts LoginOptions) (LoginResult, error) {
+	if len(username) == 0 {
+		return LoginResult{}, errors.New("username is empty")
+	}
+	if len(opts.AskPass) == 0 {
+		return LoginResult{}, errors.New("askpass helper is empty")
+	}
+
+	promptLog, err := os.CreateTemp("", "lpass-prompts-*")
+	if err != nil {
+		return LoginResult{}, fmt.Errorf("error creating prompt log: %w", err)
+	}
+	_ = promptLog.Close()
+	defer os.Remove(promptLog.Name())
+
+	args := []string{"login", "--color=never"}
+	if opts.Trust {
+		args = append(args, "--trust")
+	}
+	cmd := ls.command(append(args, username)...)
+	// command only sets the environment when the Service has extra variables.
+	if cmd.Env == nil {
+		cmd.Env = os.Environ()
+	}
+	cmd.Env = append(cmd.Env, "LPASS_ASKPASS="+opts.AskPass, PromptLogEnv+"="+promptLog.Name())
+
+	stdout, stderr, err := run(ctx, cmd, nil)
+
+	var res LoginResult
+	if logged, readErr := os.ReadFile(promptLog.Name()); readErr == nil {
+		res.Prompts = parsePrompts(string(logged), false)
+	}
+	res.Prompts = append(res.Prompts, parsePrompts(string(stdout)+"\n"+string(stderr), true)...)
+
+	if err != nil {
+		return res, fmt.Errorf("error running lpass login for '%s': %w", username, loginError(err, res.Prompts))
+	}
+
+	for _, l := range strings.Split(string(stdout), "\n") {
+		if msg, ok := strings.CutPrefix(strings.TrimSpace(l), "Success:"); ok {
+			res.Message = strings.TrimSpace(msg)
+		}
+	}
+
+	return res, nil
+}
+
+// parsePrompts returns the kinds of the prompts in s, one per line. Output
+// printed by lpass only counts for the prompts it doesn't pass to askpass, and
+// not for its error message.
+func parsePrompts(s string, printed bool) []PromptKind {
+	var kinds []PromptKind
+	for _, l := range strings.Split(s, "\n") {
+		kind := ParsePrompt(l)
+		if printed && (kind != PromptOutOfBand && kind != PromptTrust || strings.HasPrefix(l, "Error:")) {
+			continue
+		}
+		if kind != promptNone {
+			kinds = append(kinds, kind)
+		}
+	}
+	return kinds
+}
+
+// loginError matches a failed login to ErrWrongPassword or ErrMFARejected.
+// lpass asks again for a rejected master password, so the prompts tell a wrong
+// password apart from a cancelled prompt.
+func loginError(err error, prompts []PromptKind) error {
+	if errors.Is(err, ErrNetwork) {
+		return err
+	}
+
+	var passwords int
+	for _, p := range prompts {
+		if p == PromptPassword {
+			passwords++
+		}
+	}
+
+	// A cancelled multifactor prompt also fails the login, but lpass only
+	// says the authentication failed or was denied when the code or approval
+	// was rejected.
+	lower := strings.ToLower(err.Error())
+	switch {
+	case strings.Contains(lower, "authentication failed"), strings.Contains(lower, "authentication denied"):
+		return fmt.Errorf("%w: %v", ErrMFARejected, err)
+	case passwords > 1, strings.Contains(lower, "invalid password"), strings.Contains(lower, "incorrect password"):
+		return fmt.Errorf("%w: %v", ErrWrongPassword, err)
+	}
+	return err
+}
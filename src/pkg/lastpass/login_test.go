@@ -0,0 +1,195 @@
+package lastpass
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// fakeAskPass answers prompts like the askpass helper of the workflow: it logs
+// each prompt and answers with FAKE_PASSWORD or FAKE_CODE. A FAKE_CODE of
+// "cancel" fails like a cancelled dialog.
+const fakeAskPass = `#!/bin/sh
+echo "$1" >> "$LPASS_PROMPT_LOG"
+case "$1" in
+  "Master Password") echo "$FAKE_PASSWORD" ;;
+  *) [ "$FAKE_CODE" = "cancel" ] && exit 1; echo "$FAKE_CODE" ;;
+esac
+`
+
+// writeScript writes an executable shell script to dir.
+func writeScript(t *testing.T, dir string, name string, body string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte(body), 0o755); err != nil {
+		t.Fatalf("writing %s: %v", name, err)
+	}
+	return path
+}
+
+func TestLastpassServiceLogin(t *testing.T) {
+	testCases := []struct {
+		name        string
+		lpass       string
+		opts        LoginOptions
+		password    string
+		code        string
+		wantPrompts []PromptKind
+		wantMessage string
+		wantErr     error
+		// wantFailed is a failure that isn't one of the login errors.
+		wantFailed bool
+	}{
+		{
+			name: "Password",
+			lpass: `#!/bin/sh
+[ "$("$LPASS_ASKPASS" "Master Password")" = "secret" ] || exit 1
+echo "Success: Logged in as $3."
+`,
+			password:    "secret",
+			wantPrompts: []PromptKind{PromptPassword},
+			wantMessage: "Logged in as user@example.com.",
+		},
+		{
+			name: "Trusted with OTP",
+			lpass: `#!/bin/sh
+[ "$3" = "--trust" ] || { echo "Error: not trusted" >&2; exit 1; }
+[ "$("$LPASS_ASKPASS" "Master Password")" = "secret" ] || exit 1
+[ "$("$LPASS_ASKPASS" "Code")" = "123456" ] || exit 1
+echo "Success: Logged in as $4."
+`,
+			opts:        LoginOptions{Trust: true},
+			password:    "secret",
+			code:        "123456",
+			wantPrompts: []PromptKind{PromptPassword, PromptOTP},
+			wantMessage: "Logged in as user@example.com.",
+		},
+		{
+			name: "Out-of-band",
+			lpass: `#!/bin/sh
+"$LPASS_ASKPASS" "Master Password" > /dev/null
+echo "Waiting for approval of out-of-band Duo Push login..." >&2
+echo "Success: Logged in as $3."
+`,
+			wantPrompts: []PromptKind{PromptPassword, PromptOutOfBand},
+			wantMessage: "Logged in as user@example.com.",
+		},
+		{
+			name: "Wrong password",
+			lpass: `#!/bin/sh
+for i in 1 2; do
+  [ "$("$LPASS_ASKPASS" "Master Password")" = "secret" ] && exit 0
+done
+echo "Error: Failed to enter correct password." >&2
+exit 1
+`,
+			password:    "wrong",
+			wantPrompts: []PromptKind{PromptPassword, PromptPassword},
+			wantErr:     ErrWrongPassword,
+		},
+		{
+			name: "OTP rejected",
+			lpass: `#!/bin/sh
+"$LPASS_ASKPASS" "Master Password" > /dev/null
+"$LPASS_ASKPASS" "Code" > /dev/null
+echo "Error: Google Authenticator authentication failed!" >&2
+exit 1
+`,
+			wantPrompts: []PromptKind{PromptPassword, PromptOTP},
+			wantErr:     ErrMFARejected,
+		},
+		{
+			name: "OTP prompt cancelled",
+			lpass: `#!/bin/sh
+"$LPASS_ASKPASS" "Master Password" > /dev/null
+"$LPASS_ASKPASS" "Code" > /dev/null || { echo "Error: Aborted multifactor authentication." >&2; exit 1; }
+exit 0
+`,
+			code:        "cancel",
+			wantPrompts: []PromptKind{PromptPassword, PromptOTP},
+			wantFailed:  true,
+		},
+		{
+			name: "Out-of-band rejected",
+			lpass: `#!/bin/sh
+"$LPASS_ASKPASS" "Master Password" > /dev/null
+echo "Waiting for approval of out-of-band Duo Push login..."
+echo "Error: Out-of-band authentication denied." >&2
+exit 1
+`,
+			wantPrompts: []PromptKind{PromptPassword, PromptOutOfBand},
+			wantErr:     ErrMFARejected,
+		},
+		{
+			name: "Network",
+			lpass: `#!/bin/sh
+"$LPASS_ASKPASS" "Master Password" > /dev/null
+echo "Error: Could not connect to server." >&2
+exit 1
+`,
+			wantPrompts: []PromptKind{PromptPassword},
+			wantErr:     ErrNetwork,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			t.Setenv("FAKE_PASSWORD", tt.password)
+			t.Setenv("FAKE_CODE", tt.code)
+
+			tt.opts.AskPass = writeScript(t, dir, "askpass", fakeAskPass)
+			ls := &Service{
+				BinPath:     writeScript(t, dir, "lpass", tt.lpass),
+				ExecCommand: exec.Command,
+			}
+
+			got, err := ls.Login(context.Background(), "user@example.com", tt.opts)
+			if !reflect.DeepEqual(got.Prompts, tt.wantPrompts) {
+				t.Errorf("Login() prompts = %v, want %v", got.Prompts, tt.wantPrompts)
+			}
+			if tt.wantFailed {
+				if err == nil || errors.Is(err, ErrMFARejected) || errors.Is(err, ErrWrongPassword) {
+					t.Errorf("Login() error = %v, want an unmatched failure", err)
+				}
+				return
+			}
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("Login() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Login() unexpected error: %v", err)
+			}
+			if got.Message != tt.wantMessage {
+				t.Errorf("Login() message = %q, want %q", got.Message, tt.wantMessage)
+			}
+		})
+	}
+}
+
+func TestParsePrompt(t *testing.T) {
+	testCases := map[string]PromptKind{
+		"Master Password":                 PromptPassword,
+		"Code":                            PromptOTP,
+		"YubiKey OTP":                     PromptOTP,
+		"Out-of-Band OTP":                 PromptOutOfBand,
+		"Trust this device":               PromptTrust,
+		"Change password":                 PromptOther,
+		"":                                promptNone,
+		"Google Authenticator Code":       PromptOTP,
+		"  master password for something": PromptPassword,
+	}
+
+	for prompt, want := range testCases {
+		if got := ParsePrompt(prompt); got != want {
+			t.Errorf("ParsePrompt(%q) = %q, want %q", prompt, got, want)
+		}
+	}
+}
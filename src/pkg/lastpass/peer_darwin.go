@@ -0,0 +1,14 @@
+package lastpass
+
+import "syscall"
+
+// Socket options from <sys/un.h>, which package syscall doesn't define.
+const (
+	solLocal     = 0
+	localPeerPID = 0x2
+)
+
+// peerPID returns the PID of the process at the other end of the Unix socket fd.
+func peerPID(fd int) (int, error) {
+	return syscall.GetsockoptInt(fd, solLocal, localPeerPID)
+}
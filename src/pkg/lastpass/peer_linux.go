@@ -0,0 +1,12 @@
+package lastpass
+
+import "syscall"
+
+// peerPID returns the PID of the process at the other end of the Unix socket fd.
+func peerPID(fd int) (int, error) {
+	cred, err := syscall.GetsockoptUcred(fd, syscall.SOL_SOCKET, syscall.SO_PEERCRED)
+	if err != nil {
+		return 0, err
+	}
+	return int(cred.Pid), nil
+}
@@ -0,0 +1,13 @@
+//go:build !linux && !darwin
+
+package lastpass
+
+import (
+	"errors"
+	"runtime"
+)
+
+// peerPID is not supported here, so Lock can't find the agent.
+func peerPID(int) (int, error) {
+	return 0, errors.New("finding the lpass agent is not supported on " + runtime.GOOS)
+}
@@ -0,0 +1,82 @@
+package lastpass
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// DefaultProfile names the account set by the username and lpass_home options.
+const DefaultProfile = "default"
+
+// profileNameRegex restricts profile names to what is safe in a file name.
+var profileNameRegex = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// Profile is a named LastPass account with its own lpass data directory.
+type Profile struct {
+	Name     string
+	Username string
+	// Home is the LPASS_HOME of the account. Empty means the lpass default.
+	Home string
+}
+
+// FileName returns the name of a file kept for the profile, like a cache or
+// the pinned entries. The default profile keeps the names used before
+// profiles existed. ParseProfiles makes sure the profile name is safe to use.
+func (p Profile) FileName(name string) string {
+	if p.Name == "" || p.Name == DefaultProfile {
+		return name
+	}
+	return p.Name + "_" + name
+}
+
+// ParseProfiles parses profile definitions, one per line, in the form
+//
+//	name: username, lpass home
+//
+// Names may only contain letters, digits, "-" and "_". Blank lines and lines starting with "#" are ignored.
+func ParseProfiles(text string) ([]Profile, error) {
+	var profiles []Profile
+	seen := map[string]bool{DefaultProfile: true}
+	homes := make(map[string]string)
+
+	for i, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		name, rest, ok := strings.Cut(line, ":")
+		name = strings.TrimSpace(name)
+		username, home, _ := strings.Cut(rest, ",")
+		username, home = strings.TrimSpace(username), strings.TrimSpace(home)
+		if !ok || name == "" || username == "" || home == "" {
+			return nil, fmt.Errorf("profile on line %d must be in the form 'name: username, lpass home'", i+1)
+		}
+		if !profileNameRegex.MatchString(name) {
+			return nil, fmt.Errorf("profile name '%s' may only contain letters, digits, '-' and '_'", name)
+		}
+		if seen[name] {
+			return nil, fmt.Errorf("profile '%s' is defined more than once", name)
+		}
+		seen[name] = true
+		if other, ok := homes[home]; ok {
+			return nil, fmt.Errorf("profiles '%s' and '%s' use the same lpass home", other, name)
+		}
+		homes[home] = name
+
+		profiles = append(profiles, Profile{Name: name, Username: username, Home: home})
+	}
+
+	return profiles, nil
+}
+
+// FindProfile returns the profile with the given name.
+func FindProfile(profiles []Profile, name string) (Profile, bool) {
+	for _, p := range profiles {
+		if p.Name == name {
+			return p, true
+		}
+	}
+	return Profile{}, false
+}
@@ -0,0 +1,96 @@
+package lastpass
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseProfiles(t *testing.T) {
+	testCases := []struct {
+		name            string
+		text            string
+		want            []Profile
+		wantErr         bool
+		expectedErrText string
+	}{
+		{
+			name: "Empty config",
+			text: "",
+			want: nil,
+		},
+		{
+			name: "Profiles, comments and blank lines",
+			text: "# accounts\nwork: me@corp.example, ~/.lpass-work\n\nPersonal_2-b: me@example.com, /Users/me/.lpass-personal\n",
+			want: []Profile{
+				{Name: "work", Username: "me@corp.example", Home: "~/.lpass-work"},
+				{Name: "Personal_2-b", Username: "me@example.com", Home: "/Users/me/.lpass-personal"},
+			},
+		},
+		{
+			name:            "Missing home",
+			text:            "work: me@corp.example",
+			wantErr:         true,
+			expectedErrText: "line 1",
+		},
+		{
+			name:            "Duplicate name",
+			text:            "work: a@example.com, ~/.a\nwork: b@example.com, ~/.b",
+			wantErr:         true,
+			expectedErrText: "defined more than once",
+		},
+		{
+			name:            "Default name",
+			text:            "default: a@example.com, ~/.a",
+			wantErr:         true,
+			expectedErrText: "defined more than once",
+		},
+		{
+			name:            "Name with a path",
+			text:            "../work: a@example.com, ~/.a",
+			wantErr:         true,
+			expectedErrText: "may only contain",
+		},
+		{
+			name:            "Name with spaces",
+			text:            "my work: a@example.com, ~/.a",
+			wantErr:         true,
+			expectedErrText: "may only contain",
+		},
+		{
+			name:            "Shared home",
+			text:            "work: a@example.com, ~/.a\npersonal: b@example.com, ~/.a",
+			wantErr:         true,
+			expectedErrText: "same lpass home",
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseProfiles(tt.text)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseProfiles() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				if !strings.Contains(err.Error(), tt.expectedErrText) {
+					t.Errorf("ParseProfiles() error = %v, want error containing %q", err, tt.expectedErrText)
+				}
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseProfiles() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFindProfile(t *testing.T) {
+	profiles := []Profile{{Name: "work"}, {Name: "personal"}}
+
+	if p, ok := FindProfile(profiles, "personal"); !ok || p.Name != "personal" {
+		t.Errorf("FindProfile(personal) = %+v, %v", p, ok)
+	}
+	if _, ok := FindProfile(profiles, "missing"); ok {
+		t.Errorf("FindProfile(missing) found a profile")
+	}
+}
@@ -0,0 +1,117 @@
+package lastpass
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+// Scope is a named set of folder patterns used to narrow searches.
+// Patterns are globs as understood by path.Match.
+type Scope struct {
+	Name    string
+	Include []string
+	Exclude []string
+}
+
+// ParseScopes parses scope definitions, one per line, in the form
+//
+//	name: Pattern, Other*, !Excluded*
+//
+// Patterns prefixed with "!" are exclusions. Blank lines and lines starting with "#" are ignored.
+func ParseScopes(text string) ([]Scope, error) {
+	var scopes []Scope
+	seen := make(map[string]bool)
+
+	for i, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		name, patterns, ok := strings.Cut(line, ":")
+		name = strings.TrimSpace(name)
+		if !ok || name == "" {
+			return nil, fmt.Errorf("scope on line %d must be in the form 'name: patterns'", i+1)
+		}
+		if seen[name] {
+			return nil, fmt.Errorf("scope '%s' is defined more than once", name)
+		}
+		seen[name] = true
+
+		s := Scope{Name: name}
+		for _, p := range strings.Split(patterns, ",") {
+			p = strings.TrimSpace(p)
+			if p == "" {
+				continue
+			}
+			exclude := strings.HasPrefix(p, "!")
+			p = strings.TrimPrefix(p, "!")
+			if _, err := path.Match(p, ""); err != nil {
+				return nil, fmt.Errorf("invalid pattern '%s' in scope '%s': %w", p, name, err)
+			}
+			if exclude {
+				s.Exclude = append(s.Exclude, p)
+			} else {
+				s.Include = append(s.Include, p)
+			}
+		}
+		scopes = append(scopes, s)
+	}
+
+	return scopes, nil
+}
+
+// FindScope returns the scope with the given name.
+func FindScope(scopes []Scope, name string) (Scope, bool) {
+	for _, s := range scopes {
+		if s.Name == name {
+			return s, true
+		}
+	}
+	return Scope{}, false
+}
+
+// Includes reports whether folder matches one of the include patterns.
+// A scope without include patterns includes every folder.
+func (s Scope) Includes(folder string) bool {
+	if len(s.Include) == 0 {
+		return true
+	}
+	return MatchFolder(s.Include, folder)
+}
+
+// Excludes reports whether folder matches one of the exclude patterns.
+func (s Scope) Excludes(folder string) bool {
+	return MatchFolder(s.Exclude, folder)
+}
+
+// MatchFolder reports whether folder, or one of its parent folders, matches any of the patterns.
+func MatchFolder(patterns []string, folder string) bool {
+	folder = strings.Trim(folder, "/")
+	for _, p := range patterns {
+		p = strings.Trim(p, "/")
+		for f := folder; f != ""; f = path.Dir(f) {
+			if ok, _ := path.Match(p, f); ok {
+				return true
+			}
+			if !strings.Contains(f, "/") {
+				break
+			}
+		}
+	}
+	return false
+}
+
+// QuoteFolder escapes the glob metacharacters in folder, so that MatchFolder
+// matches it literally.
+func QuoteFolder(folder string) string {
+	var b strings.Builder
+	for _, r := range folder {
+		if strings.ContainsRune(`*?[\`, r) {
+			b.WriteByte('\\')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
@@ -0,0 +1,134 @@
+package lastpass
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseScopes(t *testing.T) {
+	testCases := []struct {
+		name            string
+		text            string
+		want            []Scope
+		wantErr         bool
+		expectedErrText string
+	}{
+		{
+			name: "Empty config",
+			text: "",
+			want: nil,
+		},
+		{
+			name: "Includes, excludes, comments and blank lines",
+			text: "# scopes\nwork: Work*, Shared-Work*, !Work/Archive*\n\npersonal: Personal\nclient-acme: !Shared-Acme*\n",
+			want: []Scope{
+				{Name: "work", Include: []string{"Work*", "Shared-Work*"}, Exclude: []string{"Work/Archive*"}},
+				{Name: "personal", Include: []string{"Personal"}},
+				{Name: "client-acme", Exclude: []string{"Shared-Acme*"}},
+			},
+		},
+		{
+			name:            "Missing name",
+			text:            "Work*, Personal",
+			wantErr:         true,
+			expectedErrText: "line 1",
+		},
+		{
+			name:            "Duplicate name",
+			text:            "work: Work\nwork: Other",
+			wantErr:         true,
+			expectedErrText: "defined more than once",
+		},
+		{
+			name:            "Invalid glob",
+			text:            "work: Work[",
+			wantErr:         true,
+			expectedErrText: "invalid pattern",
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseScopes(tt.text)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseScopes() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				if !strings.Contains(err.Error(), tt.expectedErrText) {
+					t.Errorf("ParseScopes() error = %v, want error containing %q", err, tt.expectedErrText)
+				}
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseScopes() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestScopeMatching(t *testing.T) {
+	s := Scope{
+		Name:    "work",
+		Include: []string{"Work*", "Shared-Work"},
+		Exclude: []string{"Work/Archive"},
+	}
+
+	testCases := []struct {
+		folder       string
+		wantIncluded bool
+		wantExcluded bool
+	}{
+		{folder: "Work", wantIncluded: true},
+		{folder: "Work Projects", wantIncluded: true},
+		{folder: "Shared-Work/Prod", wantIncluded: true},
+		{folder: "Work/Archive", wantIncluded: true, wantExcluded: true},
+		{folder: "Work/Archive/2019", wantIncluded: true, wantExcluded: true},
+		{folder: "Personal", wantIncluded: false},
+		{folder: "", wantIncluded: false},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.folder, func(t *testing.T) {
+			if got := s.Includes(tt.folder); got != tt.wantIncluded {
+				t.Errorf("Includes(%q) = %v, want %v", tt.folder, got, tt.wantIncluded)
+			}
+			if got := s.Excludes(tt.folder); got != tt.wantExcluded {
+				t.Errorf("Excludes(%q) = %v, want %v", tt.folder, got, tt.wantExcluded)
+			}
+		})
+	}
+
+	if !(Scope{}).Includes("Anything") {
+		t.Errorf("scope without include patterns should include every folder")
+	}
+}
+
+func TestQuoteFolder(t *testing.T) {
+	testCases := []struct {
+		folder string
+		match  []string
+		miss   []string
+	}{
+		{folder: "Old [2020]", match: []string{"Old [2020]", "Old [2020]/Mail"}, miss: []string{"Old 2"}},
+		{folder: "Misc*", match: []string{"Misc*"}, miss: []string{"Misc", "Miscellaneous"}},
+		{folder: "What?", match: []string{"What?"}, miss: []string{"Whats"}},
+		{folder: `Back\slash`, match: []string{`Back\slash`}, miss: []string{"Backslash"}},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.folder, func(t *testing.T) {
+			patterns := []string{QuoteFolder(tt.folder)}
+			for _, f := range tt.match {
+				if !MatchFolder(patterns, f) {
+					t.Errorf("MatchFolder(%q, %q) = false, want true", patterns, f)
+				}
+			}
+			for _, f := range tt.miss {
+				if MatchFolder(patterns, f) {
+					t.Errorf("MatchFolder(%q, %q) = true, want false", patterns, f)
+				}
+			}
+		})
+	}
+}
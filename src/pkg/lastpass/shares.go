@@ -0,0 +1,182 @@
+package lastpass
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// sharedFolderPrefix starts the name of every shared folder.
+const sharedFolderPrefix = "Shared-"
+
+// Permissions a user can have in a shared folder.
+const (
+	PermissionReadOnly  = "read-only"
+	PermissionReadWrite = "read-write"
+	PermissionAdmin     = "admin"
+)
+
+// ShareUser is a member of a shared folder as listed by lpass share userls.
+type ShareUser struct {
+	Name              string
+	Username          string
+	ReadOnly          bool
+	Admin             bool
+	HidePasswords     bool
+	OutsideEnterprise bool
+	Accepted          bool
+}
+
+// Permission returns the user's permission in the shared folder.
+func (u ShareUser) Permission() string {
+	switch {
+	case u.Admin:
+		return PermissionAdmin
+	case u.ReadOnly:
+		return PermissionReadOnly
+	default:
+		return PermissionReadWrite
+	}
+}
+
+// ShareAccess is what a member of a shared folder is allowed to do.
+type ShareAccess struct {
+	ReadOnly      bool
+	Admin         bool
+	HidePasswords bool
+}
+
+// Access returns the user's access to the shared folder.
+func (u ShareUser) Access() ShareAccess {
+	return ShareAccess{ReadOnly: u.ReadOnly, Admin: u.Admin, HidePasswords: u.HidePasswords}
+}
+
+// args returns the lpass share useradd and usermod flags for the access.
+func (a ShareAccess) args() []string {
+	return []string{
+		"--read-only=" + strconv.FormatBool(a.ReadOnly),
+		"--admin=" + strconv.FormatBool(a.Admin),
+		"--hidden=" + strconv.FormatBool(a.HidePasswords),
+	}
+}
+
+// GetShareUsers lists the members of a shared folder.
+func (ls *Service) GetShareUsers(ctx context.Context, share string) ([]ShareUser, error) {
+	share = strings.TrimSuffix(share, "/")
+	if share == "" {
+		return nil, errors.New("share is empty")
+	}
+
+	cmd := ls.command("share", "userls", share)
+	out, err := output(ctx, cmd, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error running lpass share userls for '%s': %w", share, err)
+	}
+
+	return parseShareUsers(string(out)), nil
+}
+
+// AddShareUser invites username to a shared folder.
+func (ls *Service) AddShareUser(ctx context.Context, share string, username string, access ShareAccess) error {
+	return ls.shareUserCommand(ctx, "useradd", share, username, access.args()...)
+}
+
+// UpdateShareUser changes what a member of a shared folder is allowed to do.
+func (ls *Service) UpdateShareUser(ctx context.Context, share string, username string, access ShareAccess) error {
+	return ls.shareUserCommand(ctx, "usermod", share, username, access.args()...)
+}
+
+// RemoveShareUser removes a member from a shared folder.
+func (ls *Service) RemoveShareUser(ctx context.Context, share string, username string) error {
+	return ls.shareUserCommand(ctx, "userdel", share, username)
+}
+
+// shareUserCommand runs lpass share with a subcommand that changes a member.
+func (ls *Service) shareUserCommand(ctx context.Context, subcommand string, share string, username string, flags ...string) error {
+	share = strings.TrimSuffix(share, "/")
+	if !strings.HasPrefix(share, sharedFolderPrefix) || strings.Contains(share, "/") {
+		return fmt.Errorf("'%s' is not a shared folder", share)
+	}
+	if username == "" {
+		return errors.New("username is empty")
+	}
+
+	args := append([]string{"share", subcommand}, flags...)
+	args = append(args, share, username)
+	cmd := ls.command(args...)
+	if _, err := output(ctx, cmd, nil); err != nil {
+		return fmt.Errorf("error running lpass share %s for '%s' in '%s': %w", subcommand, username, share, err)
+	}
+
+	return nil
+}
+
+// SharePermission returns the permission of username in users, or an empty string if username is not a member.
+func SharePermission(users []ShareUser, username string) string {
+	for _, u := range users {
+		if strings.EqualFold(u.Username, username) {
+			return u.Permission()
+		}
+	}
+	return ""
+}
+
+// SetSharePermissions fills in the permission of each shared folder from
+// perms, which maps share names to permissions as returned by SharePermission.
+func SetSharePermissions(folders []Folder, perms map[string]string) {
+	for i, f := range folders {
+		if f.Shared {
+			folders[i].Permission = perms[f.Share()]
+		}
+	}
+}
+
+// parseShareUsers parses the table printed by lpass share userls, e.g.
+//
+//	User                                         RO  Admin   Hide OutEnt Accept
+//	Jane Doe <jane@example.com>                   _      x      _      _      x
+func parseShareUsers(output string) []ShareUser {
+	users := []ShareUser{}
+
+	for _, l := range strings.Split(output, "\n") {
+		fields := strings.Fields(l)
+		if len(fields) < 6 {
+			continue
+		}
+
+		flags := fields[len(fields)-5:]
+		if !isCheckmarks(flags) {
+			// Header lines
+			continue
+		}
+
+		name := strings.Join(fields[:len(fields)-5], " ")
+		u := ShareUser{
+			Name:              name,
+			Username:          name,
+			ReadOnly:          flags[0] == "x",
+			Admin:             flags[1] == "x",
+			HidePasswords:     flags[2] == "x",
+			OutsideEnterprise: flags[3] == "x",
+			Accepted:          flags[4] == "x",
+		}
+		if start, end := strings.LastIndex(name, "<"), strings.LastIndex(name, ">"); start >= 0 && end > start {
+			u.Username = name[start+1 : end]
+			u.Name = strings.TrimSpace(name[:start])
+		}
+		users = append(users, u)
+	}
+
+	return users
+}
+
+func isCheckmarks(fields []string) bool {
+	for _, f := range fields {
+		if f != "x" && f != "_" {
+			return false
+		}
+	}
+	return true
+}
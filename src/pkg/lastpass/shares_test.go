@@ -0,0 +1,81 @@
+package lastpass
+
+import (
+	"context"
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+func TestLastpassServiceShareUserChanges(t *testing.T) {
+	testCases := []struct {
+		name        string
+		run         func(ls *Service) error
+		wantCommand string
+		wantErr     bool
+	}{
+		{
+			name: "Add read-only member with hidden passwords",
+			run: func(ls *Service) error {
+				return ls.AddShareUser(context.Background(), "Shared-Team/", "jane@example.com", ShareAccess{ReadOnly: true, HidePasswords: true})
+			},
+			wantCommand: "share useradd --read-only=true --admin=false --hidden=true Shared-Team jane@example.com",
+		},
+		{
+			name: "Make member admin",
+			run: func(ls *Service) error {
+				return ls.UpdateShareUser(context.Background(), "Shared-Team", "jane@example.com", ShareAccess{Admin: true})
+			},
+			wantCommand: "share usermod --read-only=false --admin=true --hidden=false Shared-Team jane@example.com",
+		},
+		{
+			name: "Remove member",
+			run: func(ls *Service) error {
+				return ls.RemoveShareUser(context.Background(), "Shared-Team", "jane@example.com")
+			},
+			wantCommand: "share userdel Shared-Team jane@example.com",
+		},
+		{
+			name: "Not a shared folder",
+			run: func(ls *Service) error {
+				return ls.RemoveShareUser(context.Background(), "Work", "jane@example.com")
+			},
+			wantErr: true,
+		},
+		{
+			name: "Subfolder of a shared folder",
+			run: func(ls *Service) error {
+				return ls.RemoveShareUser(context.Background(), "Shared-Team/Sub", "jane@example.com")
+			},
+			wantErr: true,
+		},
+		{
+			name: "Empty username",
+			run: func(ls *Service) error {
+				return ls.AddShareUser(context.Background(), "Shared-Team", "", ShareAccess{})
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			var command string
+			ls := &Service{
+				BinPath: "lpass",
+				ExecCommand: func(name string, args ...string) *exec.Cmd {
+					command = strings.Join(args, " ")
+					return mockExecCommand(t, "", "", 0)(name, args...)
+				},
+			}
+
+			err := tt.run(ls)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if command != tt.wantCommand {
+				t.Errorf("ran lpass %q, want %q", command, tt.wantCommand)
+			}
+		})
+	}
+}
@@ -0,0 +1,268 @@
+package lastpass
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// defaultAgentTimeout is how long the lpass agent keeps the decryption key
+// when LPASS_AGENT_TIMEOUT is not set.
+const defaultAgentTimeout = time.Hour
+
+// agentSocket is the name of the socket the lpass agent listens on.
+const agentSocket = "agent.sock"
+
+// Status describes the LastPass session.
+type Status struct {
+	LoggedIn bool
+	Account  string
+	// AgentTimeout is how long the agent keeps the decryption key. Zero means
+	// until logout.
+	AgentTimeout time.Duration
+	// BlobModified is when the local copy of the vault last changed. It is zero
+	// without a local copy.
+	BlobModified time.Time
+	// LastSync is the time of the last successful Sync. It is zero if unknown.
+	LastSync time.Time
+}
+
+// Status returns the state of the LastPass session.
+func (ls *Service) Status(ctx context.Context) (Status, error) {
+	cmd := ls.command("status", "--color=never")
+	out, err := output(ctx, cmd, nil)
+
+	var st Status
+	switch {
+	case err == nil:
+		st.LoggedIn = true
+		st.Account = parseStatusAccount(string(out))
+	case strings.Contains(string(out), "Not logged in"):
+	default:
+		return Status{}, fmt.Errorf("error running lpass status: %w", err)
+	}
+
+	st.AgentTimeout = agentTimeout(ls.getenv("LPASS_AGENT_TIMEOUT"))
+
+	if blob, err := dataPath(ls.getenv("LPASS_HOME"), "blob"); err == nil {
+		if info, err := os.Stat(blob); err == nil {
+			st.BlobModified = info.ModTime()
+		}
+	}
+
+	if ls.SyncFile != "" {
+		if data, err := os.ReadFile(ls.SyncFile); err == nil {
+			st.LastSync, _ = time.Parse(time.RFC3339, strings.TrimSpace(string(data)))
+		}
+	}
+
+	return st, nil
+}
+
+// SyncResult describes what a Sync changed in the local vault.
+type SyncResult struct {
+	// Duration is how long lpass took to sync.
+	Duration time.Duration
+	Added    int
+	Removed  int
+	Modified int
+	// Entries is the listing after the sync.
+	Entries []Entry
+}
+
+// Sync fetches the vault from LastPass and counts the changes by comparing the
+// listing before and after. The time of a successful sync is written to
+// SyncFile, when set, for Status.
+func (ls *Service) Sync(ctx context.Context) (SyncResult, error) {
+	before, err := ls.GetEntries("", nil, nil, false)
+	if err != nil {
+		return SyncResult{}, err
+	}
+
+	start := time.Now()
+	cmd := ls.command("sync", "--color=never")
+	if _, err := output(ctx, cmd, nil); err != nil {
+		return SyncResult{}, fmt.Errorf("error running lpass sync: %w", err)
+	}
+	duration := time.Since(start)
+
+	after, err := ls.GetEntries("", nil, nil, false)
+	if err != nil {
+		return SyncResult{}, err
+	}
+
+	res := diffEntries(before, after)
+	res.Duration = duration
+	res.Entries = after
+
+	if ls.SyncFile == "" {
+		return res, nil
+	}
+	if err := os.WriteFile(ls.SyncFile, []byte(time.Now().UTC().Format(time.RFC3339)), 0o600); err != nil {
+		return res, fmt.Errorf("error recording sync time: %w", err)
+	}
+	return res, nil
+}
+
+// diffEntries counts the entries added, removed and modified between two
+// listings. LastUsed is ignored, as using an entry doesn't change it.
+func diffEntries(before []Entry, after []Entry) SyncResult {
+	old := make(map[string]Entry, len(before))
+	for _, e := range before {
+		old[e.ID] = e
+	}
+
+	var res SyncResult
+	for _, e := range after {
+		prev, ok := old[e.ID]
+		if !ok {
+			res.Added++
+			continue
+		}
+		delete(old, e.ID)
+		if prev.Name != e.Name || prev.Folder != e.Folder || prev.URL != e.URL || prev.Username != e.Username ||
+			prev.Password != e.Password || !prev.LastModified.Equal(e.LastModified) {
+			res.Modified++
+		}
+	}
+	res.Removed = len(old)
+	return res
+}
+
+// Logout ends the session. lpass removes the local vault with it.
+func (ls *Service) Logout(ctx context.Context) error {
+	cmd := ls.command("logout", "--force", "--color=never")
+	if _, err := output(ctx, cmd, nil); err != nil {
+		return fmt.Errorf("error running lpass logout: %w", err)
+	}
+
+	if ls.SyncFile != "" {
+		if err := os.Remove(ls.SyncFile); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("error removing sync time: %w", err)
+		}
+	}
+	return nil
+}
+
+// Lock stops the lpass agent, so the next command asks for the master password
+// again. Unlike logout, the session and the local vault are kept. Only the
+// agent of this LPASS_HOME is stopped: the one listening on its socket.
+func (ls *Service) Lock(ctx context.Context) error {
+	sock, err := runtimePath(ls.getenv("LPASS_HOME"), agentSocket)
+	if err != nil {
+		return fmt.Errorf("error finding lpass agent: %w", err)
+	}
+
+	pid, err := agentPID(ctx, sock)
+	if err != nil {
+		return fmt.Errorf("error finding lpass agent: %w", err)
+	}
+	if pid == 0 {
+		return nil
+	}
+	if err := stopProcess(pid); err != nil {
+		return fmt.Errorf("error stopping lpass agent: %w", err)
+	}
+	return nil
+}
+
+// stopProcess asks the process pid to exit. Tests replace it.
+var stopProcess = func(pid int) error {
+	return syscall.Kill(pid, syscall.SIGTERM)
+}
+
+// agentPID returns the PID of the process listening on the agent socket sock,
+// or 0 when no agent is running. Only the listener is the peer of a
+// connection, so commands that are talking to the agent are left alone.
+func agentPID(ctx context.Context, sock string) (int, error) {
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "unix", sock)
+	if errors.Is(err, os.ErrNotExist) || errors.Is(err, syscall.ECONNREFUSED) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	defer conn.Close()
+
+	raw, err := conn.(*net.UnixConn).SyscallConn()
+	if err != nil {
+		return 0, err
+	}
+	var pid int
+	var pidErr error
+	if err := raw.Control(func(fd uintptr) { pid, pidErr = peerPID(int(fd)) }); err != nil {
+		return 0, err
+	}
+	return pid, pidErr
+}
+
+// parseStatusAccount returns the account of "Logged in as user@example.com.".
+func parseStatusAccount(out string) string {
+	for _, l := range strings.Split(out, "\n") {
+		if account, ok := strings.CutPrefix(strings.TrimSpace(l), "Logged in as "); ok {
+			return strings.TrimSuffix(account, ".")
+		}
+	}
+	return ""
+}
+
+// agentTimeout parses LPASS_AGENT_TIMEOUT, in seconds, like lpass does.
+func agentTimeout(v string) time.Duration {
+	if v == "" {
+		return defaultAgentTimeout
+	}
+	secs, err := strconv.Atoi(v)
+	if err != nil || secs < 0 {
+		return defaultAgentTimeout
+	}
+	return time.Duration(secs) * time.Second
+}
+
+// dataPath returns the path of a file lpass keeps its data in: lpassHome,
+// ~/.lpass or the XDG data directory, in that order.
+func dataPath(lpassHome string, name string) (string, error) {
+	if lpassHome != "" {
+		return filepath.Join(lpassHome, name), nil
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	if info, err := os.Stat(filepath.Join(home, ".lpass")); err == nil && info.IsDir() {
+		return filepath.Join(home, ".lpass", name), nil
+	}
+
+	data := os.Getenv("XDG_DATA_HOME")
+	if data == "" {
+		data = filepath.Join(home, ".local", "share")
+	}
+	return filepath.Join(data, "lpass", name), nil
+}
+
+// runtimePath returns the path of a file lpass keeps while it runs, like the
+// agent socket: lpassHome, ~/.lpass or the XDG runtime directory, in that
+// order. Without a runtime directory lpass falls back to its data directory.
+func runtimePath(lpassHome string, name string) (string, error) {
+	runtime := os.Getenv("XDG_RUNTIME_DIR")
+	if lpassHome != "" || runtime == "" {
+		return dataPath(lpassHome, name)
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	if info, err := os.Stat(filepath.Join(home, ".lpass")); err == nil && info.IsDir() {
+		return filepath.Join(home, ".lpass", name), nil
+	}
+	return filepath.Join(runtime, "lpass", name), nil
+}
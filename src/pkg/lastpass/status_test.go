@@ -0,0 +1,263 @@
+package lastpass
+
+import (
+	"context"
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestLastpassServiceStatus(t *testing.T) {
+	blobTime := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	syncTime := time.Date(2024, 3, 2, 8, 30, 0, 0, time.UTC)
+
+	testCases := []struct {
+		name         string
+		mockStdout   string
+		mockExitCode int
+		agentTimeout string
+		blob         bool
+		syncFile     string
+		want         Status
+		wantErr      bool
+	}{
+		{
+			name:       "Logged in",
+			mockStdout: "Logged in as user@example.com.\n",
+			blob:       true,
+			syncFile:   syncTime.Format(time.RFC3339),
+			want: Status{
+				LoggedIn:     true,
+				Account:      "user@example.com",
+				AgentTimeout: time.Hour,
+				BlobModified: blobTime,
+				LastSync:     syncTime,
+			},
+		},
+		{
+			name:         "Agent never times out",
+			mockStdout:   "Logged in as user@example.com.\n",
+			agentTimeout: "0",
+			want:         Status{LoggedIn: true, Account: "user@example.com"},
+		},
+		{
+			name:         "Agent timeout",
+			mockStdout:   "Logged in as user@example.com.\n",
+			agentTimeout: "300",
+			want:         Status{LoggedIn: true, Account: "user@example.com", AgentTimeout: 5 * time.Minute},
+		},
+		{
+			name:         "Not logged in",
+			mockStdout:   "Not logged in.\n",
+			mockExitCode: 1,
+			want:         Status{AgentTimeout: time.Hour},
+		},
+		{
+			name:         "Failed",
+			mockExitCode: 2,
+			wantErr:      true,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			home := t.TempDir()
+			t.Setenv("LPASS_HOME", home)
+			t.Setenv("LPASS_AGENT_TIMEOUT", tt.agentTimeout)
+
+			if tt.blob {
+				blob := filepath.Join(home, "blob")
+				if err := os.WriteFile(blob, []byte("blob"), 0o600); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.Chtimes(blob, blobTime, blobTime); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			ls := &Service{
+				BinPath:     "lpass",
+				ExecCommand: mockExecCommand(t, tt.mockStdout, "", tt.mockExitCode),
+			}
+			if tt.syncFile != "" {
+				ls.SyncFile = filepath.Join(home, "last_sync")
+				if err := os.WriteFile(ls.SyncFile, []byte(tt.syncFile), 0o600); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			got, err := ls.Status(context.Background())
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("Status() expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Status() unexpected error: %v", err)
+			}
+			if got.LoggedIn != tt.want.LoggedIn || got.Account != tt.want.Account || got.AgentTimeout != tt.want.AgentTimeout ||
+				!got.BlobModified.Equal(tt.want.BlobModified) || !got.LastSync.Equal(tt.want.LastSync) {
+				t.Errorf("Status() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLastpassServiceStatusEnv(t *testing.T) {
+	t.Setenv("LPASS_AGENT_TIMEOUT", "")
+
+	// The extra environment reaches lpass and overrides the mocked output.
+	ls := &Service{
+		BinPath:     "lpass",
+		ExecCommand: mockExecCommand(t, "Logged in as user@example.com.", "", 0),
+		Env:         []string{"LPASS_AGENT_TIMEOUT=60", "MOCK_STDOUT=Logged in as other@example.com."},
+	}
+
+	got, err := ls.Status(context.Background())
+	if err != nil {
+		t.Fatalf("Status() unexpected error: %v", err)
+	}
+	if got.Account != "other@example.com" {
+		t.Errorf("Status().Account = %q, want %q", got.Account, "other@example.com")
+	}
+	if got.AgentTimeout != time.Minute {
+		t.Errorf("Status().AgentTimeout = %v, want %v", got.AgentTimeout, time.Minute)
+	}
+}
+
+func TestLastpassServiceSync(t *testing.T) {
+	syncFile := filepath.Join(t.TempDir(), "last_sync")
+
+	ls := &Service{
+		BinPath:     "lpass",
+		ExecCommand: mockExecCommand(t, "", "Error: Could not connect to server.", 1),
+		SyncFile:    syncFile,
+	}
+	if _, err := ls.Sync(context.Background()); err == nil {
+		t.Fatal("Sync() expected error, got nil")
+	}
+	if _, err := os.Stat(syncFile); !os.IsNotExist(err) {
+		t.Errorf("Sync() recorded a failed sync")
+	}
+
+	ls.ExecCommand = mockExecCommand(t, "Work/Mail [id: 1] [modified: 2024-01-01 10:00] [used: ] [url: ] [username: me] pw", "", 0)
+	before := time.Now().Add(-time.Second)
+	res, err := ls.Sync(context.Background())
+	if err != nil {
+		t.Fatalf("Sync() unexpected error: %v", err)
+	}
+	if res.Added != 0 || res.Removed != 0 || res.Modified != 0 || len(res.Entries) != 1 {
+		t.Errorf("Sync() = %+v, want no changes and 1 entry", res)
+	}
+	st, err := ls.Status(context.Background())
+	if err != nil {
+		t.Fatalf("Status() unexpected error: %v", err)
+	}
+	if st.LastSync.Before(before) {
+		t.Errorf("Status().LastSync = %v, want after %v", st.LastSync, before)
+	}
+}
+
+func TestDiffEntries(t *testing.T) {
+	modified := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	mail := Entry{ID: "1", Name: "Mail", Folder: "Work", Username: "me", Password: "pw", LastModified: modified}
+	bank := Entry{ID: "2", Name: "Bank", Password: "secret", LastModified: modified}
+
+	renamed := mail
+	renamed.Name = "Webmail"
+	rotated := bank
+	rotated.Password = "new"
+	used := mail
+	used.LastUsed = time.Now()
+
+	testCases := []struct {
+		name   string
+		before []Entry
+		after  []Entry
+		want   SyncResult
+	}{
+		{name: "No changes", before: []Entry{mail, bank}, after: []Entry{bank, mail}},
+		{name: "Only used", before: []Entry{mail}, after: []Entry{used}},
+		{name: "Added", before: []Entry{mail}, after: []Entry{mail, bank}, want: SyncResult{Added: 1}},
+		{name: "Removed", before: []Entry{mail, bank}, after: []Entry{bank}, want: SyncResult{Removed: 1}},
+		{name: "Modified", before: []Entry{mail, bank}, after: []Entry{renamed, rotated}, want: SyncResult{Modified: 2}},
+		{name: "First sync", after: []Entry{mail, bank}, want: SyncResult{Added: 2}},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			got := diffEntries(tt.before, tt.after)
+			if got.Added != tt.want.Added || got.Removed != tt.want.Removed || got.Modified != tt.want.Modified {
+				t.Errorf("diffEntries() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLastpassServiceLock(t *testing.T) {
+	testCases := []struct {
+		name     string
+		listen   bool
+		stale    bool
+		stopErr  error
+		wantStop bool
+		wantErr  bool
+	}{
+		{name: "Agent stopped", listen: true, wantStop: true},
+		{name: "No agent running"},
+		{name: "Socket left behind", stale: true},
+		{name: "Stop failed", listen: true, stopErr: errors.New("operation not permitted"), wantStop: true, wantErr: true},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			home := t.TempDir()
+			sock := filepath.Join(home, agentSocket)
+			if tt.listen || tt.stale {
+				l, err := net.Listen("unix", sock)
+				if err != nil {
+					t.Fatalf("listening on %s: %v", sock, err)
+				}
+				if tt.stale {
+					l.(*net.UnixListener).SetUnlinkOnClose(false)
+					l.Close()
+				} else {
+					defer l.Close()
+				}
+			}
+			// An agent for another LPASS_HOME must be left alone.
+			other, err := net.Listen("unix", filepath.Join(t.TempDir(), agentSocket))
+			if err != nil {
+				t.Fatalf("listening: %v", err)
+			}
+			defer other.Close()
+
+			var stopped []int
+			orig := stopProcess
+			stopProcess = func(pid int) error {
+				stopped = append(stopped, pid)
+				return tt.stopErr
+			}
+			defer func() { stopProcess = orig }()
+
+			ls := &Service{BinPath: "lpass", Env: []string{"LPASS_HOME=" + home}}
+			if err := ls.Lock(context.Background()); (err != nil) != tt.wantErr {
+				t.Errorf("Lock() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			// The test process itself listens on the socket.
+			var want []int
+			if tt.wantStop {
+				want = []int{os.Getpid()}
+			}
+			if !reflect.DeepEqual(stopped, want) {
+				t.Errorf("Lock() stopped %v, want %v", stopped, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,59 @@
+package lastpass
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// MinVersion is the oldest lpass release with every command and flag the
+// Service uses.
+const MinVersion = "1.3.0"
+
+var versionRegex = regexp.MustCompile(`v?(\d+)\.(\d+)(?:\.(\d+))?`)
+
+// Version returns the version of lpass, like "1.6.1".
+func (ls *Service) Version(ctx context.Context) (string, error) {
+	cmd := ls.command("--version")
+	out, err := output(ctx, cmd, nil)
+	if err != nil {
+		return "", fmt.Errorf("error running lpass --version: %w", err)
+	}
+
+	m := versionRegex.FindStringSubmatch(string(out))
+	if m == nil {
+		return "", fmt.Errorf("unknown lpass version '%s'", strings.TrimSpace(string(out)))
+	}
+	if m[3] == "" {
+		m[3] = "0"
+	}
+	return strings.Join(m[1:], "."), nil
+}
+
+// SupportedVersion reports whether version is MinVersion or newer.
+func SupportedVersion(version string) bool {
+	return compareVersions(version, MinVersion) >= 0
+}
+
+// compareVersions compares dotted version numbers like strings.Compare.
+func compareVersions(a string, b string) int {
+	as, bs := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < len(as) || i < len(bs); i++ {
+		var x, y int
+		if i < len(as) {
+			x, _ = strconv.Atoi(as[i])
+		}
+		if i < len(bs) {
+			y, _ = strconv.Atoi(bs[i])
+		}
+		if x != y {
+			if x < y {
+				return -1
+			}
+			return 1
+		}
+	}
+	return 0
+}
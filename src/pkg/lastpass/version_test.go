@@ -0,0 +1,55 @@
+package lastpass
+
+import (
+	"context"
+	"testing"
+)
+
+func TestLastpassServiceVersion(t *testing.T) {
+	testCases := []struct {
+		name         string
+		mockStdout   string
+		mockExitCode int
+		want         string
+		wantErr      bool
+	}{
+		{name: "Release", mockStdout: "LastPass CLI v1.6.1\n", want: "1.6.1"},
+		{name: "Without patch", mockStdout: "LastPass CLI v1.3\n", want: "1.3.0"},
+		{name: "Unknown", mockStdout: "LastPass CLI\n", wantErr: true},
+		{name: "Failed", mockExitCode: 1, wantErr: true},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			ls := &Service{
+				BinPath:     "lpass",
+				ExecCommand: mockExecCommand(t, tt.mockStdout, "", tt.mockExitCode),
+			}
+
+			got, err := ls.Version(context.Background())
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Version() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("Version() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSupportedVersion(t *testing.T) {
+	testCases := map[string]bool{
+		"1.6.1":  true,
+		"1.3.0":  true,
+		"1.10.0": true,
+		"2.0.0":  true,
+		"1.2.9":  false,
+		"0.9.0":  false,
+	}
+
+	for version, want := range testCases {
+		if got := SupportedVersion(version); got != want {
+			t.Errorf("SupportedVersion(%q) = %v, want %v", version, got, want)
+		}
+	}
+}
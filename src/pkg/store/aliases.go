@@ -0,0 +1,78 @@
+package store
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Aliases maps short user-defined aliases to entry IDs and persists them as JSON.
+type Aliases struct {
+	path    string
+	Entries map[string]string `json:"aliases"`
+}
+
+// LoadAliases reads the aliases file at path. A missing file returns an empty set.
+func LoadAliases(path string) (*Aliases, error) {
+	a := &Aliases{path: path, Entries: map[string]string{}}
+	if err := readJSON(path, "aliases", a); err != nil {
+		return nil, err
+	}
+	if a.Entries == nil {
+		a.Entries = map[string]string{}
+	}
+
+	return a, nil
+}
+
+// Save writes the aliases back to disk.
+func (a *Aliases) Save() error {
+	return writeJSON(a.path, "aliases", a)
+}
+
+// Set maps alias to the entry ID, replacing any existing mapping.
+func (a *Aliases) Set(alias string, id string) error {
+	alias = normaliseAlias(alias)
+	if alias == "" {
+		return errors.New("alias is empty")
+	}
+	if strings.ContainsAny(alias, " \t") {
+		return fmt.Errorf("alias '%s' must not contain spaces", alias)
+	}
+	if id == "" {
+		return errors.New("entry ID is empty")
+	}
+	a.Entries[alias] = id
+	return nil
+}
+
+// Remove deletes alias and reports whether it existed.
+func (a *Aliases) Remove(alias string) bool {
+	alias = normaliseAlias(alias)
+	if _, ok := a.Entries[alias]; !ok {
+		return false
+	}
+	delete(a.Entries, alias)
+	return true
+}
+
+// Lookup returns the entry ID for an exact alias match.
+func (a *Aliases) Lookup(alias string) (string, bool) {
+	id, ok := a.Entries[normaliseAlias(alias)]
+	return id, ok
+}
+
+// Names returns all aliases in sorted order.
+func (a *Aliases) Names() []string {
+	names := make([]string, 0, len(a.Entries))
+	for name := range a.Entries {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+func normaliseAlias(alias string) string {
+	return strings.ToLower(strings.TrimSpace(alias))
+}
@@ -0,0 +1,53 @@
+package store
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestAliases(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "aliases.json")
+
+	a, err := LoadAliases(path)
+	if err != nil {
+		t.Fatalf("LoadAliases() on missing file error = %v", err)
+	}
+
+	if err := a.Set("GH", "100"); err != nil {
+		t.Fatalf("Set(GH) error = %v", err)
+	}
+	if err := a.Set("vpn", "200"); err != nil {
+		t.Fatalf("Set(vpn) error = %v", err)
+	}
+	if err := a.Set("two words", "300"); err == nil {
+		t.Errorf("Set() with spaces expected an error, got nil")
+	}
+	if err := a.Set("", "300"); err == nil {
+		t.Errorf("Set() with empty alias expected an error, got nil")
+	}
+	if err := a.Save(); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	loaded, err := LoadAliases(path)
+	if err != nil {
+		t.Fatalf("LoadAliases() error = %v", err)
+	}
+	if id, ok := loaded.Lookup(" gh "); !ok || id != "100" {
+		t.Errorf("Lookup(gh) = %q, %v, want 100, true", id, ok)
+	}
+	if !reflect.DeepEqual(loaded.Names(), []string{"gh", "vpn"}) {
+		t.Errorf("Names() = %v, want [gh vpn]", loaded.Names())
+	}
+
+	if !loaded.Remove("VPN") {
+		t.Errorf("Remove(VPN) = false, want true")
+	}
+	if loaded.Remove("vpn") {
+		t.Errorf("second Remove(vpn) = true, want false")
+	}
+	if _, ok := loaded.Lookup("vpn"); ok {
+		t.Errorf("Lookup(vpn) after Remove found an entry")
+	}
+}
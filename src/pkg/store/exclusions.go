@@ -0,0 +1,47 @@
+package store
+
+import (
+	"slices"
+	"strings"
+)
+
+// Exclusions holds folders that have been excluded from the global search.
+type Exclusions struct {
+	path    string
+	Folders []string `json:"folders"`
+}
+
+// LoadExclusions reads the exclusions file at path. A missing file returns an empty set.
+func LoadExclusions(path string) (*Exclusions, error) {
+	e := &Exclusions{path: path, Folders: []string{}}
+	if err := readJSON(path, "folder exclusions", e); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+// Save writes the exclusions back to disk.
+func (e *Exclusions) Save() error {
+	return writeJSON(e.path, "folder exclusions", e)
+}
+
+// IsExcluded reports whether folder has been excluded.
+func (e *Exclusions) IsExcluded(folder string) bool {
+	return slices.Contains(e.Folders, normaliseFolder(folder))
+}
+
+// Toggle excludes or includes folder and returns whether it is excluded afterwards.
+func (e *Exclusions) Toggle(folder string) bool {
+	folder = normaliseFolder(folder)
+	if i := slices.Index(e.Folders, folder); i >= 0 {
+		e.Folders = slices.Delete(e.Folders, i, i+1)
+		return false
+	}
+	e.Folders = append(e.Folders, folder)
+	return true
+}
+
+// normaliseFolder strips the trailing slash lpass prints after folder names.
+func normaliseFolder(folder string) string {
+	return strings.Trim(folder, "/")
+}
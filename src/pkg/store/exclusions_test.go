@@ -0,0 +1,36 @@
+package store
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestExclusionsToggle(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "exclusions.json")
+
+	e, err := LoadExclusions(path)
+	if err != nil {
+		t.Fatalf("LoadExclusions() on missing file error = %v", err)
+	}
+
+	if !e.Toggle("Archive/") {
+		t.Errorf("Toggle(Archive/) = false, want true")
+	}
+	if err := e.Save(); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	loaded, err := LoadExclusions(path)
+	if err != nil {
+		t.Fatalf("LoadExclusions() error = %v", err)
+	}
+	if !loaded.IsExcluded("Archive") {
+		t.Errorf("IsExcluded(Archive) = false, want true")
+	}
+	if loaded.Toggle("Archive") {
+		t.Errorf("second Toggle(Archive) = true, want false")
+	}
+	if loaded.IsExcluded("Archive/") {
+		t.Errorf("IsExcluded(Archive/) after toggle = true, want false")
+	}
+}
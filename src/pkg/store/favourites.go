@@ -0,0 +1,47 @@
+package store
+
+import "slices"
+
+// Favourites holds the IDs of pinned entries and persists them as JSON.
+type Favourites struct {
+	path string
+	IDs  []string `json:"ids"`
+}
+
+// LoadFavourites reads the favourites file at path. A missing file returns an empty set.
+func LoadFavourites(path string) (*Favourites, error) {
+	f := &Favourites{path: path, IDs: []string{}}
+	if err := readJSON(path, "favourites", f); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// Save writes the favourites back to disk.
+func (f *Favourites) Save() error {
+	return writeJSON(f.path, "favourites", f)
+}
+
+// IsPinned reports whether the entry with the given ID is pinned.
+func (f *Favourites) IsPinned(id string) bool {
+	return slices.Contains(f.IDs, id)
+}
+
+// Toggle pins or unpins an entry and returns whether it is pinned afterwards.
+func (f *Favourites) Toggle(id string) bool {
+	if i := slices.Index(f.IDs, id); i >= 0 {
+		f.IDs = slices.Delete(f.IDs, i, i+1)
+		return false
+	}
+	f.IDs = append(f.IDs, id)
+	return true
+}
+
+// Prune drops pins for IDs not in existing and returns how many were removed.
+func (f *Favourites) Prune(existing []string) int {
+	before := len(f.IDs)
+	f.IDs = slices.DeleteFunc(f.IDs, func(id string) bool {
+		return !slices.Contains(existing, id)
+	})
+	return before - len(f.IDs)
+}
@@ -0,0 +1,98 @@
+package store
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/rwilgaard/alfred-lastpass-search/src/pkg/lastpass"
+)
+
+func TestFavouritesToggleAndSave(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "favourites.json")
+
+	f, err := LoadFavourites(path)
+	if err != nil {
+		t.Fatalf("LoadFavourites() on missing file error = %v", err)
+	}
+	if len(f.IDs) != 0 {
+		t.Fatalf("LoadFavourites() on missing file IDs = %v, want empty", f.IDs)
+	}
+
+	if !f.Toggle("100") {
+		t.Errorf("Toggle(100) = false, want true")
+	}
+	if !f.Toggle("200") {
+		t.Errorf("Toggle(200) = false, want true")
+	}
+	if f.Toggle("100") {
+		t.Errorf("second Toggle(100) = true, want false")
+	}
+	if err := f.Save(); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	loaded, err := LoadFavourites(path)
+	if err != nil {
+		t.Fatalf("LoadFavourites() error = %v", err)
+	}
+	if !reflect.DeepEqual(loaded.IDs, []string{"200"}) {
+		t.Errorf("LoadFavourites() IDs = %v, want [200]", loaded.IDs)
+	}
+	if !loaded.IsPinned("200") || loaded.IsPinned("100") {
+		t.Errorf("IsPinned() mismatch for IDs %v", loaded.IDs)
+	}
+}
+
+func TestFavouritesPrune(t *testing.T) {
+	f := &Favourites{IDs: []string{"1", "2", "3"}}
+
+	removed := f.Prune([]string{"1", "3", "4"})
+	if removed != 1 {
+		t.Errorf("Prune() removed = %d, want 1", removed)
+	}
+	if !reflect.DeepEqual(f.IDs, []string{"1", "3"}) {
+		t.Errorf("Prune() IDs = %v, want [1 3]", f.IDs)
+	}
+}
+
+func TestFavouritesPrunePerProfile(t *testing.T) {
+	dir := t.TempDir()
+	work := lastpass.Profile{Name: "work"}
+	personal := lastpass.Profile{Name: lastpass.DefaultProfile}
+	path := func(p lastpass.Profile) string {
+		return filepath.Join(dir, p.FileName("favourites.json"))
+	}
+
+	for p, ids := range map[lastpass.Profile][]string{work: {"100", "200"}, personal: {"100", "300"}} {
+		f, err := LoadFavourites(path(p))
+		if err != nil {
+			t.Fatalf("LoadFavourites(%s) error = %v", p.Name, err)
+		}
+		f.IDs = ids
+		if err := f.Save(); err != nil {
+			t.Fatalf("Save(%s) error = %v", p.Name, err)
+		}
+	}
+
+	f, err := LoadFavourites(path(work))
+	if err != nil {
+		t.Fatalf("LoadFavourites(work) error = %v", err)
+	}
+	if removed := f.Prune([]string{"100"}); removed != 1 {
+		t.Errorf("Prune() removed = %d, want 1", removed)
+	}
+	if err := f.Save(); err != nil {
+		t.Fatalf("Save(work) error = %v", err)
+	}
+
+	for p, want := range map[lastpass.Profile][]string{work: {"100"}, personal: {"100", "300"}} {
+		f, err := LoadFavourites(path(p))
+		if err != nil {
+			t.Fatalf("LoadFavourites(%s) error = %v", p.Name, err)
+		}
+		if !reflect.DeepEqual(f.IDs, want) {
+			t.Errorf("pins of %s = %v, want %v", p.Name, f.IDs, want)
+		}
+	}
+}
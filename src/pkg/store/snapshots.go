@@ -0,0 +1,213 @@
+package store
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+const (
+	snapshotExt      = ".json"
+	snapshotIDLayout = "20060102T150405.000000000Z"
+	saltFile         = "salt"
+	saltSize         = 32
+)
+
+// SnapshotEntry is the metadata of an entry kept in a snapshot. It holds no
+// secrets: the password is only kept as a salted fingerprint.
+type SnapshotEntry struct {
+	ID string `json:"id"`
+	// Name is the name of the entry without its folders, and Folder the full
+	// folder path, so a rename and a move to a subfolder are told apart.
+	Name                string    `json:"name"`
+	Folder              string    `json:"folder"`
+	URL                 string    `json:"url"`
+	Username            string    `json:"username"`
+	LastModified        time.Time `json:"last_modified"`
+	PasswordFingerprint string    `json:"password_fingerprint"`
+}
+
+// Snapshot is the vault metadata at the time of a sync.
+type Snapshot struct {
+	// ID is the name of the snapshot file, which sorts by time.
+	ID      string          `json:"-"`
+	Time    time.Time       `json:"time"`
+	Entries []SnapshotEntry `json:"entries"`
+}
+
+// Snapshots keeps snapshots in a directory, one JSON file each, along with the
+// salt for the password fingerprints.
+type Snapshots struct {
+	dir  string
+	keep int
+	salt []byte
+}
+
+// OpenSnapshots opens the snapshot directory dir, creating it and its salt if
+// needed. Only the newest keep snapshots are kept.
+func OpenSnapshots(dir string, keep int) (*Snapshots, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("error creating snapshot directory: %w", err)
+	}
+
+	path := filepath.Join(dir, saltFile)
+	salt, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		salt = make([]byte, saltSize)
+		if _, err := rand.Read(salt); err != nil {
+			return nil, err
+		}
+		if err := os.WriteFile(path, salt, 0o600); err != nil {
+			return nil, fmt.Errorf("error writing snapshot salt: %w", err)
+		}
+	} else if err != nil {
+		return nil, fmt.Errorf("error reading snapshot salt: %w", err)
+	}
+
+	return &Snapshots{dir: dir, keep: keep, salt: salt}, nil
+}
+
+// Fingerprint returns the salted fingerprint of password. It tells whether a
+// password changed without revealing it. An empty password has none.
+func (s *Snapshots) Fingerprint(password string) string {
+	if password == "" {
+		return ""
+	}
+	mac := hmac.New(sha256.New, s.salt)
+	mac.Write([]byte(password))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Add writes a snapshot of entries taken at t and removes the oldest snapshots
+// beyond the number to keep.
+func (s *Snapshots) Add(t time.Time, entries []SnapshotEntry) (Snapshot, error) {
+	t = t.UTC()
+	// IDs are unique and sort by time, even for clocks too coarse to tell
+	// two snapshots apart.
+	for {
+		if _, err := os.Stat(s.path(t.Format(snapshotIDLayout))); errors.Is(err, os.ErrNotExist) {
+			break
+		}
+		t = t.Add(time.Nanosecond)
+	}
+	snap := Snapshot{ID: t.Format(snapshotIDLayout), Time: t, Entries: entries}
+	if err := writeJSON(s.path(snap.ID), "snapshot", snap); err != nil {
+		return Snapshot{}, err
+	}
+
+	ids, err := s.IDs()
+	if err != nil {
+		return Snapshot{}, err
+	}
+	if s.keep > 0 && len(ids) > s.keep {
+		for _, id := range ids[s.keep:] {
+			if err := os.Remove(s.path(id)); err != nil {
+				return Snapshot{}, fmt.Errorf("error removing snapshot: %w", err)
+			}
+		}
+	}
+	return snap, nil
+}
+
+// IDs returns the IDs of the snapshots, newest first.
+func (s *Snapshots) IDs() ([]string, error) {
+	files, err := os.ReadDir(s.dir)
+	if err != nil {
+		return nil, fmt.Errorf("error reading snapshots: %w", err)
+	}
+
+	var ids []string
+	for _, f := range files {
+		if id, ok := strings.CutSuffix(f.Name(), snapshotExt); ok && !f.IsDir() {
+			ids = append(ids, id)
+		}
+	}
+	slices.Sort(ids)
+	slices.Reverse(ids)
+	return ids, nil
+}
+
+// Load reads the snapshot with the given ID.
+func (s *Snapshots) Load(id string) (Snapshot, error) {
+	if id == "" || strings.ContainsAny(id, `/\`) {
+		return Snapshot{}, fmt.Errorf("invalid snapshot '%s'", id)
+	}
+	if _, err := os.Stat(s.path(id)); err != nil {
+		return Snapshot{}, fmt.Errorf("snapshot '%s' does not exist", id)
+	}
+
+	snap := Snapshot{ID: id}
+	if err := readJSON(s.path(id), "snapshot", &snap); err != nil {
+		return Snapshot{}, err
+	}
+	return snap, nil
+}
+
+func (s *Snapshots) path(id string) string {
+	return filepath.Join(s.dir, id+snapshotExt)
+}
+
+// ChangeKind is how an entry changed between two snapshots.
+type ChangeKind string
+
+const (
+	ChangeAdded           ChangeKind = "added"
+	ChangeRemoved         ChangeKind = "removed"
+	ChangeRenamed         ChangeKind = "renamed"
+	ChangeMoved           ChangeKind = "moved"
+	ChangePasswordRotated ChangeKind = "password rotated"
+)
+
+// Change is one change to an entry. Entry is the entry as it is in the newer
+// snapshot, or as it was for a removed entry. Old is the previous name or
+// folder of a renamed or moved entry.
+type Change struct {
+	Kind  ChangeKind
+	Entry SnapshotEntry
+	Old   string
+}
+
+// DiffSnapshots lists the changes from the older snapshot to the newer one. An
+// entry that was renamed and moved has a change for each. The changes are
+// ordered by the newer snapshot, followed by the removed entries.
+func DiffSnapshots(older Snapshot, newer Snapshot) []Change {
+	old := make(map[string]SnapshotEntry, len(older.Entries))
+	for _, e := range older.Entries {
+		old[e.ID] = e
+	}
+
+	var changes []Change
+	for _, e := range newer.Entries {
+		prev, ok := old[e.ID]
+		if !ok {
+			changes = append(changes, Change{Kind: ChangeAdded, Entry: e})
+			continue
+		}
+		delete(old, e.ID)
+
+		if prev.Name != e.Name {
+			changes = append(changes, Change{Kind: ChangeRenamed, Entry: e, Old: prev.Name})
+		}
+		if prev.Folder != e.Folder {
+			changes = append(changes, Change{Kind: ChangeMoved, Entry: e, Old: prev.Folder})
+		}
+		if prev.PasswordFingerprint != e.PasswordFingerprint {
+			changes = append(changes, Change{Kind: ChangePasswordRotated, Entry: e})
+		}
+	}
+
+	for _, e := range older.Entries {
+		if _, ok := old[e.ID]; ok {
+			changes = append(changes, Change{Kind: ChangeRemoved, Entry: e})
+		}
+	}
+	return changes
+}
@@ -0,0 +1,146 @@
+package store
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestSnapshots(t *testing.T) {
+	dir := t.TempDir()
+
+	s, err := OpenSnapshots(dir, 2)
+	if err != nil {
+		t.Fatalf("OpenSnapshots() error = %v", err)
+	}
+
+	fp := s.Fingerprint("s3cret")
+	if fp == "" || fp == "s3cret" || fp != s.Fingerprint("s3cret") || fp == s.Fingerprint("other") {
+		t.Errorf("Fingerprint() = %q, want a stable hash of the password", fp)
+	}
+	if s.Fingerprint("") != "" {
+		t.Errorf("Fingerprint(\"\") = %q, want empty", s.Fingerprint(""))
+	}
+
+	reopened, err := OpenSnapshots(dir, 2)
+	if err != nil {
+		t.Fatalf("OpenSnapshots() second time error = %v", err)
+	}
+	if reopened.Fingerprint("s3cret") != fp {
+		t.Errorf("Fingerprint() changed after reopening")
+	}
+	other, err := OpenSnapshots(t.TempDir(), 2)
+	if err != nil {
+		t.Fatalf("OpenSnapshots() error = %v", err)
+	}
+	if other.Fingerprint("s3cret") == fp {
+		t.Errorf("Fingerprint() is the same with another salt")
+	}
+
+	start := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	entries := []SnapshotEntry{{ID: "1", Name: "Mail", PasswordFingerprint: fp}}
+	for i := range 3 {
+		if _, err := s.Add(start.Add(time.Duration(i)*time.Hour), entries); err != nil {
+			t.Fatalf("Add() error = %v", err)
+		}
+	}
+
+	ids, err := s.IDs()
+	if err != nil {
+		t.Fatalf("IDs() error = %v", err)
+	}
+	if want := []string{"20240301T140000.000000000Z", "20240301T130000.000000000Z"}; !reflect.DeepEqual(ids, want) {
+		t.Errorf("IDs() = %v, want %v", ids, want)
+	}
+
+	snap, err := s.Load(ids[0])
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if !snap.Time.Equal(start.Add(2*time.Hour)) || !reflect.DeepEqual(snap.Entries, entries) {
+		t.Errorf("Load() = %+v, want the last snapshot", snap)
+	}
+	if _, err := s.Load("20240301T120000.000000000Z"); err == nil {
+		t.Errorf("Load() of a pruned snapshot expected an error, got nil")
+	}
+	if _, err := s.Load("../salt"); err == nil {
+		t.Errorf("Load() of a path expected an error, got nil")
+	}
+
+	// Two syncs within the same clock tick keep both snapshots.
+	same := start.Add(3*time.Hour + 500*time.Millisecond)
+	first, err := s.Add(same, entries)
+	if err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+	second, err := s.Add(same, nil)
+	if err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+	if ids, _ := s.IDs(); !reflect.DeepEqual(ids, []string{second.ID, first.ID}) {
+		t.Errorf("IDs() = %v, want [%s %s]", ids, second.ID, first.ID)
+	}
+	if first.ID != "20240301T150000.500000000Z" || !second.Time.After(first.Time) {
+		t.Errorf("Add() twice at %v = %s and %s, want distinct IDs in order", same, first.ID, second.ID)
+	}
+}
+
+func TestDiffSnapshots(t *testing.T) {
+	mail := SnapshotEntry{ID: "1", Name: "Mail", Folder: "Shared-Work", PasswordFingerprint: "a"}
+	bank := SnapshotEntry{ID: "2", Name: "Bank", Folder: "Personal", PasswordFingerprint: "b"}
+	vpn := SnapshotEntry{ID: "3", Name: "VPN", Folder: "Shared-Work", PasswordFingerprint: "c"}
+
+	renamed := mail
+	renamed.Name = "Webmail"
+	renamed.Folder = "Shared-IT"
+	rotated := bank
+	rotated.PasswordFingerprint = "d"
+	touched := vpn
+	touched.LastModified = time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	testCases := []struct {
+		name  string
+		older []SnapshotEntry
+		newer []SnapshotEntry
+		want  []Change
+	}{
+		{
+			name:  "No changes",
+			older: []SnapshotEntry{mail, bank},
+			newer: []SnapshotEntry{bank, mail},
+		},
+		{
+			name:  "Only modified time",
+			older: []SnapshotEntry{vpn},
+			newer: []SnapshotEntry{touched},
+		},
+		{
+			name:  "Added and removed",
+			older: []SnapshotEntry{mail, bank},
+			newer: []SnapshotEntry{mail, vpn},
+			want: []Change{
+				{Kind: ChangeAdded, Entry: vpn},
+				{Kind: ChangeRemoved, Entry: bank},
+			},
+		},
+		{
+			name:  "Renamed, moved and rotated",
+			older: []SnapshotEntry{mail, bank},
+			newer: []SnapshotEntry{renamed, rotated},
+			want: []Change{
+				{Kind: ChangeRenamed, Entry: renamed, Old: "Mail"},
+				{Kind: ChangeMoved, Entry: renamed, Old: "Shared-Work"},
+				{Kind: ChangePasswordRotated, Entry: rotated},
+			},
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			got := DiffSnapshots(Snapshot{Entries: tt.older}, Snapshot{Entries: tt.newer})
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("DiffSnapshots() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
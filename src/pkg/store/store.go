@@ -0,0 +1,39 @@
+// Package store persists small pieces of workflow state, such as pinned
+// entries, aliases and vault snapshots, as JSON files in the workflow data
+// directory.
+package store
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+)
+
+// readJSON decodes the file at path into v. A missing file leaves v untouched.
+func readJSON(path string, what string, v any) error {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("error reading %s: %w", what, err)
+	}
+
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("error parsing %s: %w", what, err)
+	}
+	return nil
+}
+
+// writeJSON encodes v and writes it to path.
+func writeJSON(path string, what string, v any) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		return fmt.Errorf("error writing %s: %w", what, err)
+	}
+	return nil
+}
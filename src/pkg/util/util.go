@@ -5,6 +5,7 @@ import (
 	"os"
 	"regexp"
 	"strings"
+	"time"
 
 	aw "github.com/deanishe/awgo"
 	"github.com/sethvargo/go-password/password"
@@ -17,6 +18,14 @@ var (
 	IconPW     = &aw.Icon{Value: "icons/password-alt.png"}
 	IconDelete = &aw.Icon{Value: "icons/trash.png"}
 	IconEdit   = &aw.Icon{Value: "icons/edit.png"}
+	IconPin    = &aw.Icon{Value: "icons/pin.png"}
+	IconAlias  = &aw.Icon{Value: "icons/alias.png"}
+	IconWarn   = &aw.Icon{Value: "icons/warning.png"}
+	IconClone  = &aw.Icon{Value: "icons/clone.png"}
+	IconSync   = &aw.Icon{Value: "icons/sync.png"}
+	IconLock   = &aw.Icon{Value: "icons/lock.png"}
+	IconLogout = &aw.Icon{Value: "icons/logout.png"}
+	IconOK     = &aw.Icon{Value: "icons/ok.png"}
 )
 
 func RegexSearch(regex *regexp.Regexp, query string) string {
@@ -69,3 +78,33 @@ func GetIcon(key string) *aw.Icon {
 
 	return &aw.Icon{Value: iconPath}
 }
+
+// RelativeTime formats t relative to now, e.g. "3 days ago". A zero t returns "never".
+func RelativeTime(t time.Time, now time.Time) string {
+	if t.IsZero() {
+		return "never"
+	}
+
+	d := now.Sub(t)
+	switch {
+	case d < time.Minute:
+		return "just now"
+	case d < time.Hour:
+		return plural(int(d/time.Minute), "minute") + " ago"
+	case d < 24*time.Hour:
+		return plural(int(d/time.Hour), "hour") + " ago"
+	case d < 30*24*time.Hour:
+		return plural(int(d/(24*time.Hour)), "day") + " ago"
+	case d < 365*24*time.Hour:
+		return plural(int(d/(30*24*time.Hour)), "month") + " ago"
+	default:
+		return plural(int(d/(365*24*time.Hour)), "year") + " ago"
+	}
+}
+
+func plural(n int, unit string) string {
+	if n == 1 {
+		return fmt.Sprintf("1 %s", unit)
+	}
+	return fmt.Sprintf("%d %ss", n, unit)
+}
@@ -0,0 +1,3 @@
+#!/bin/sh
+# lpass runs LPASS_ASKPASS with the prompt as its only argument.
+cd "$(dirname "$0")" && exec ./alfred-lastpass-search askpass "$@"